@@ -1,10 +1,10 @@
 module github.com/uoregon-libraries/dark-archive-validator
 
-go 1.12
+go 1.13
 
 require (
 	github.com/jessevdk/go-flags v1.1.0
-	golang.org/x/crypto v0.0.0-20191108234033-bd318be0434a // indirect
+	golang.org/x/crypto v0.0.0-20191108234033-bd318be0434a
 	golang.org/x/lint v0.0.0-20190930215403-16217165b5de // indirect
 	golang.org/x/net v0.0.0-20191109021931-daa7c04131f5 // indirect
 	golang.org/x/sync v0.0.0-20190911185100-cd5d95a43a6e // indirect
@@ -0,0 +1,176 @@
+package checksum
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Cache remembers files' digests between runs so unchanged files needn't be
+// read again.  A cached entry is only used if the file's path, size,
+// modification time, and inode all still match.  Hits and Misses count
+// lookups since the cache was loaded.
+//
+// The cache also tracks which paths have been seen since it was loaded, so
+// entries for files which have since been deleted or moved can be pruned.
+type Cache struct {
+	// Rehash makes every lookup miss, so all files are read again, while
+	// still storing the new digests
+	Rehash bool
+	Hits   int
+	Misses int
+
+	entries map[string]*cacheEntry
+	seen    map[string]bool
+}
+
+// cacheEntry is one file's cached digests, keyed by algorithm name, along
+// with what we knew about the file when they were computed
+type cacheEntry struct {
+	Size    int64             `json:"size"`
+	ModTime int64             `json:"mtime"`
+	Inode   uint64            `json:"inode"`
+	Sums    map[string]string `json:"sums"`
+}
+
+// cacheFile is the on-disk format of a cache
+type cacheFile struct {
+	Version int                    `json:"version"`
+	Entries map[string]*cacheEntry `json:"entries"`
+}
+
+const cacheVersion = 1
+
+// NewCache returns an empty cache
+func NewCache() *Cache {
+	return &Cache{entries: make(map[string]*cacheEntry), seen: make(map[string]bool)}
+}
+
+// LoadCache reads a cache file.  A file which doesn't exist yet, or was
+// written by an incompatible version, is treated as an empty cache.
+func LoadCache(filename string) (*Cache, error) {
+	var data, err = ioutil.ReadFile(filename)
+	if os.IsNotExist(err) {
+		return NewCache(), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var cf cacheFile
+	err = json.Unmarshal(data, &cf)
+	if err != nil {
+		return nil, err
+	}
+
+	var c = NewCache()
+	if cf.Version == cacheVersion && cf.Entries != nil {
+		c.entries = cf.Entries
+	}
+	return c, nil
+}
+
+// Save writes the cache to filename, replacing it only once the new file has
+// been fully written
+func (c *Cache) Save(filename string) error {
+	var data, err = json.Marshal(cacheFile{Version: cacheVersion, Entries: c.entries})
+	if err != nil {
+		return err
+	}
+
+	var f *os.File
+	f, err = ioutil.TempFile(filepath.Dir(filename), filepath.Base(filename)+".tmp")
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	var closeErr = f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), filename)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+func newCacheEntry(info os.FileInfo) *cacheEntry {
+	return &cacheEntry{
+		Size:    info.Size(),
+		ModTime: info.ModTime().UnixNano(),
+		Inode:   inode(info),
+		Sums:    make(map[string]string),
+	}
+}
+
+func (e *cacheEntry) matches(other *cacheEntry) bool {
+	return e.Size == other.Size && e.ModTime == other.ModTime && e.Inode == other.Inode
+}
+
+// Lookup returns the cached digests for the file at path, keyed by
+// algorithm, if the file hasn't changed and every requested algorithm's
+// digest is cached
+func (c *Cache) Lookup(path string, info os.FileInfo, algs []string) (map[string]string, bool) {
+	c.seen[path] = true
+	var e = c.entries[path]
+	if c.Rehash || e == nil || !e.matches(newCacheEntry(info)) {
+		c.Misses++
+		return nil, false
+	}
+
+	var sums = make(map[string]string)
+	for _, alg := range algs {
+		var sum, ok = e.Sums[alg]
+		if !ok {
+			c.Misses++
+			return nil, false
+		}
+		sums[alg] = sum
+	}
+
+	c.Hits++
+	return sums, true
+}
+
+// Store caches the file's digests, keeping any other algorithms' digests
+// already cached if the file hasn't changed
+func (c *Cache) Store(path string, info os.FileInfo, sums map[string]string) {
+	c.seen[path] = true
+	var e = newCacheEntry(info)
+	var old = c.entries[path]
+	if old != nil && old.matches(e) {
+		e = old
+	}
+	for alg, sum := range sums {
+		e.Sums[alg] = sum
+	}
+	c.entries[path] = e
+}
+
+// Keep marks path as seen, so Prune won't remove its entry even though it
+// was never looked up
+func (c *Cache) Keep(path string) {
+	c.seen[path] = true
+}
+
+// Prune removes the entries for every path under root which hasn't been seen
+// since the cache was loaded, returning how many were removed.  Entries
+// outside root are left alone, as they may belong to other trees.
+func (c *Cache) Prune(root string) int {
+	root = filepath.Clean(root)
+	var prefix = strings.TrimSuffix(root, string(filepath.Separator)) + string(filepath.Separator)
+	var n int
+	for path := range c.entries {
+		if c.seen[path] || (path != root && !strings.HasPrefix(path, prefix)) {
+			continue
+		}
+		delete(c.entries, path)
+		n++
+	}
+	return n
+}
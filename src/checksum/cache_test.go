@@ -0,0 +1,124 @@
+package checksum
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCache(t *testing.T) {
+	var dir, err = ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var path = filepath.Join(dir, "file.txt")
+	ioutil.WriteFile(path, []byte("hello\n"), 0644)
+	var info, _ = os.Stat(path)
+
+	var c = NewCache()
+	c.Store(path, info, map[string]string{"md5": "abc"})
+	var sums, ok = c.Lookup(path, info, []string{"md5"})
+	if !ok || sums["md5"] != "abc" {
+		t.Errorf("Expected a cache hit with md5 abc, got %v %#v", ok, sums)
+	}
+	_, ok = c.Lookup(path, info, []string{"md5", "sha256"})
+	if ok {
+		t.Errorf("Expected a miss when an algorithm isn't cached")
+	}
+
+	var cacheFile = filepath.Join(dir, "cache.json")
+	err = c.Save(cacheFile)
+	if err != nil {
+		t.Fatalf("Unable to save cache: %s", err)
+	}
+	c, err = LoadCache(cacheFile)
+	if err != nil {
+		t.Fatalf("Unable to load cache: %s", err)
+	}
+	sums, ok = c.Lookup(path, info, []string{"md5"})
+	if !ok || sums["md5"] != "abc" {
+		t.Errorf("Expected a cache hit after reloading, got %v %#v", ok, sums)
+	}
+
+	c.Rehash = true
+	_, ok = c.Lookup(path, info, []string{"md5"})
+	if ok {
+		t.Errorf("Expected a miss when rehashing")
+	}
+	c.Rehash = false
+
+	ioutil.WriteFile(path, []byte("changed\n"), 0644)
+	info, _ = os.Stat(path)
+	_, ok = c.Lookup(path, info, []string{"md5"})
+	if ok {
+		t.Errorf("Expected a miss after the file changed")
+	}
+
+	if c.Hits != 1 || c.Misses != 2 {
+		t.Errorf("Expected 1 hit and 2 misses, got %d and %d", c.Hits, c.Misses)
+	}
+}
+
+func TestLoadCacheMissing(t *testing.T) {
+	var c, err = LoadCache(filepath.Join(os.TempDir(), "no-such-cache-file.json"))
+	if err != nil || c == nil {
+		t.Errorf("Expected an empty cache for a missing file, got %v", err)
+	}
+}
+
+func TestCachePrune(t *testing.T) {
+	var dir, err = ioutil.TempDir("", "cache")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var root = filepath.Join(dir, "root")
+	var paths = map[string]string{
+		"looked-up": filepath.Join(root, "looked-up.txt"),
+		"kept":      filepath.Join(root, "kept.txt"),
+		"gone":      filepath.Join(root, "sub", "gone.txt"),
+		"elsewhere": filepath.Join(dir, "rootless.txt"),
+	}
+	var c = NewCache()
+	for _, path := range paths {
+		os.MkdirAll(filepath.Dir(path), 0755)
+		ioutil.WriteFile(path, []byte("hello\n"), 0644)
+		var info, _ = os.Stat(path)
+		c.Store(path, info, map[string]string{"md5": "abc"})
+	}
+
+	var cacheFile = filepath.Join(dir, "cache.json")
+	err = c.Save(cacheFile)
+	if err == nil {
+		c, err = LoadCache(cacheFile)
+	}
+	if err != nil {
+		t.Fatalf("Unable to save and reload cache: %s", err)
+	}
+
+	var info, _ = os.Stat(paths["looked-up"])
+	c.Lookup(paths["looked-up"], info, []string{"md5"})
+	c.Keep(paths["kept"])
+	var n = c.Prune(root + string(filepath.Separator))
+	if n != 1 {
+		t.Errorf("Expected 1 entry to be pruned, got %d", n)
+	}
+	for name, path := range paths {
+		var _, cached = c.entries[path]
+		if cached == (name == "gone") {
+			t.Errorf("Expected %s to be cached: %v, got %v", name, name != "gone", cached)
+		}
+	}
+}
+
+func TestCacheSaveError(t *testing.T) {
+	var c = NewCache()
+	var err = c.Save(filepath.Join(os.TempDir(), "no-such-dir", "cache.json"))
+	if err == nil {
+		t.Errorf("Expected an error saving to a directory which doesn't exist")
+	}
+}
@@ -1,31 +1,148 @@
 package checksum
 
 import (
+	"crypto/md5"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
 	"hash"
 	"io"
 	"os"
+	"sort"
+
+	"golang.org/x/crypto/blake2b"
 )
 
+// Algorithms maps the names we use for hash algorithms (in options and
+// manifest filenames) to their constructors
+var Algorithms = map[string]func() hash.Hash{
+	"md5":    md5.New,
+	"sha1":   sha1.New,
+	"sha256": sha256.New,
+	"sha512": sha512.New,
+	"blake2b": func() hash.Hash {
+		// New512 only fails when given an over-long key
+		var h, _ = blake2b.New512(nil)
+		return h
+	},
+}
+
+// AlgorithmNames returns the known algorithm names, sorted
+func AlgorithmNames() []string {
+	var names []string
+	for name := range Algorithms {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// NewHash returns a new hash for the named algorithm
+func NewHash(name string) (hash.Hash, error) {
+	var fn, ok = Algorithms[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown hash algorithm %q", name)
+	}
+	return fn(), nil
+}
+
 // Checksum combines a hash method with a block writing function for easily
 // checksumming files by path, and customizing the hash or the read/write
-// method used
+// method used.  Name, if set, is the main hash's algorithm name.  Extra, if
+// set, holds additional named hashes which are computed from the same read of
+// the file.
 type Checksum struct {
+	Name       string
 	Hash       hash.Hash
 	BlockWrite func(path string, w io.Writer) error
+	Extra      map[string]hash.Hash
 }
 
 // New returns a new Checksum using the default block write method, which just
-// uses io.Copy to send a stream of bytes from the file into the hash
+// uses io.Copy to send a stream of bytes from the file into the hash, paced
+// by ReadLimit if it's set
 func New(h hash.Hash) *Checksum {
-	return &Checksum{h, defaultBlockWrite}
+	return &Checksum{Hash: h, BlockWrite: defaultBlockWrite}
+}
+
+// NewAlgorithm returns a new Checksum using the default block write method
+// and the named algorithm
+func NewAlgorithm(name string) (*Checksum, error) {
+	var h, err = NewHash(name)
+	if err != nil {
+		return nil, err
+	}
+	var c = New(h)
+	c.Name = name
+	return c, nil
+}
+
+// AddAlgorithm sets up the named algorithm to be computed alongside the main
+// hash
+func (f *Checksum) AddAlgorithm(name string) error {
+	var h, err = NewHash(name)
+	if err != nil {
+		return err
+	}
+	if f.Extra == nil {
+		f.Extra = make(map[string]hash.Hash)
+	}
+	f.Extra[name] = h
+	return nil
 }
 
 // Sum resets the hash, runs the given path through BlockWrite, and returns the
 // final hash Sum
 func (f *Checksum) Sum(path string) ([]byte, error) {
+	var sum, _, err = f.SumAll(path)
+	return sum, err
+}
+
+// SumAll is like Sum, but also returns the sums of all Extra hashes, keyed by
+// algorithm name.  The file is only read once no matter how many hashes are
+// computed.
+func (f *Checksum) SumAll(path string) ([]byte, map[string][]byte, error) {
+	var writers = []io.Writer{f.Hash}
 	f.Hash.Reset()
-	var err = f.BlockWrite(path, f.Hash)
-	return f.Hash.Sum(nil), err
+	for _, h := range f.Extra {
+		h.Reset()
+		writers = append(writers, h)
+	}
+
+	var err = f.BlockWrite(path, io.MultiWriter(writers...))
+	var extra map[string][]byte
+	if len(f.Extra) > 0 {
+		extra = make(map[string][]byte)
+	}
+	for name, h := range f.Extra {
+		extra[name] = h.Sum(nil)
+	}
+	return f.Hash.Sum(nil), extra, err
+}
+
+// PartialSum hashes only the first and last n bytes of the file at path,
+// which must be size bytes long.  Files no larger than 2n are hashed in full.
+// This is a cheap way to rule out most files which merely share a size.
+func PartialSum(h hash.Hash, path string, size, n int64) ([]byte, error) {
+	var f, err = os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	h.Reset()
+	var w = Throttled(h)
+	if size <= 2*n {
+		_, err = io.Copy(w, f)
+		return h.Sum(nil), err
+	}
+
+	_, err = io.Copy(w, io.NewSectionReader(f, 0, n))
+	if err == nil {
+		_, err = io.Copy(w, io.NewSectionReader(f, size-n, n))
+	}
+	return h.Sum(nil), err
 }
 
 func defaultBlockWrite(path string, w io.Writer) error {
@@ -35,7 +152,7 @@ func defaultBlockWrite(path string, w io.Writer) error {
 	}
 	defer f.Close()
 
-	_, err = io.Copy(w, f)
+	_, err = io.Copy(Throttled(w), f)
 	if err != nil {
 		return err
 	}
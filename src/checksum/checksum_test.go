@@ -0,0 +1,146 @@
+package checksum
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func fakeBlockWrite(path string, w io.Writer) error {
+	var _, err = io.WriteString(w, "hello\n")
+	return err
+}
+
+func TestSumAll(t *testing.T) {
+	var c = &Checksum{Hash: sha256.New(), BlockWrite: fakeBlockWrite}
+	for _, name := range []string{"md5", "sha1", "sha512", "blake2b"} {
+		var err = c.AddAlgorithm(name)
+		if err != nil {
+			t.Fatalf("Unable to add %s: %s", name, err)
+		}
+	}
+
+	var expected = map[string]string{
+		"sha256": "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+		"md5":    "b1946ac92492d2347c6235b4d2611184",
+		"sha1":   "f572d396fae9206628714fb2ce00f72e94f2258f",
+		"sha512": "e7c22b994c59d9cf2b48e549b1e24666636045930d3da7c1acb299d1c3b7f931" +
+			"f94aae41edda2c2b207a36e10f8bcb8d45223e54878f5b316e7ce3b6bc019629",
+		"blake2b": "f60ce482e5cc1229f39d71313171a8d9f4ca3a87d066bf4b205effb528192a75" +
+			"f14f3271e2c1a90e1de53f275b4d4793eef2f5e31ea90d2ce29d2e481c36435f",
+	}
+
+	// Summing twice makes sure every hash is reset between files
+	for i := 0; i < 2; i++ {
+		var sum, extra, err = c.SumAll("fake")
+		if err != nil {
+			t.Fatalf("Unexpected error: %s", err)
+		}
+		var got = map[string]string{"sha256": fmt.Sprintf("%x", sum)}
+		for name, s := range extra {
+			got[name] = fmt.Sprintf("%x", s)
+		}
+		for name, want := range expected {
+			if got[name] != want {
+				t.Errorf("Expected %s to be %s, got %s", name, want, got[name])
+			}
+		}
+	}
+}
+
+func TestAlgorithmUnknown(t *testing.T) {
+	var c = New(sha256.New())
+	if c.AddAlgorithm("crc99") == nil {
+		t.Errorf("Expected an error for an unknown algorithm")
+	}
+	var _, err = NewAlgorithm("crc99")
+	if err == nil {
+		t.Errorf("Expected an error for an unknown algorithm")
+	}
+}
+
+func TestReadManifest(t *testing.T) {
+	var manifest = "# written by hand\n" +
+		"ABC123  a/b.txt\n" +
+		"def456 *binary.dat\n" +
+		"\n" +
+		"789abc  name with  spaces.txt\n" +
+		"\\fedcba  new\\nline\\\\.txt\n" +
+		"aaa111   leading space.txt\n" +
+		"bbb222  *star.txt\n" +
+		"ccc333 **binary star.txt\n"
+	var sums, err = ReadManifest(strings.NewReader(manifest))
+	if err != nil {
+		t.Fatalf("Unexpected error: %s", err)
+	}
+
+	var expected = map[string]string{
+		"a/b.txt":               "abc123",
+		"binary.dat":            "def456",
+		"name with  spaces.txt": "789abc",
+		"new\nline\\.txt":       "fedcba",
+		" leading space.txt":    "aaa111",
+		"*star.txt":             "bbb222",
+		"*binary star.txt":      "ccc333",
+	}
+	if len(sums) != len(expected) {
+		t.Errorf("Expected %d entries, got %d: %#v", len(expected), len(sums), sums)
+	}
+	for path, digest := range expected {
+		if sums[path] != digest {
+			t.Errorf("Expected %q to have digest %q, got %q", path, digest, sums[path])
+		}
+	}
+
+	for _, line := range []string{"nopath\n", "abc123 single-space.txt\n", "abc123\ttab.txt\n", "abc123  \n"} {
+		_, err = ReadManifest(strings.NewReader(line))
+		if err == nil {
+			t.Errorf("Expected an error for %q", line)
+		}
+	}
+}
+
+func TestPartialSum(t *testing.T) {
+	var dir, err = ioutil.TempDir("", "partial")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var write = func(name, contents string) string {
+		var path = filepath.Join(dir, name)
+		ioutil.WriteFile(path, []byte(contents), 0644)
+		return path
+	}
+	var sum = func(path string, size int64) string {
+		var s, err = PartialSum(sha256.New(), path, size, 4)
+		if err != nil {
+			t.Fatalf("Unable to sum %s: %s", path, err)
+		}
+		return fmt.Sprintf("%x", s)
+	}
+
+	// Only the middle differs, so the partial sums match
+	var a = write("a", "head-AAAA-tail")
+	var b = write("b", "head-BBBB-tail")
+	if sum(a, 14) != sum(b, 14) {
+		t.Errorf("Expected files differing only in the middle to have the same partial sum")
+	}
+
+	// The tail differs
+	var c = write("c", "head-AAAA-TAIL")
+	if sum(a, 14) == sum(c, 14) {
+		t.Errorf("Expected files with different tails to have different partial sums")
+	}
+
+	// Small files are hashed in full
+	var d = write("d", "short")
+	if sum(d, 5) != fmt.Sprintf("%x", sha256.Sum256([]byte("short"))) {
+		t.Errorf("Expected a small file's partial sum to be its full sum")
+	}
+}
@@ -0,0 +1,14 @@
+//go:build !aix && !darwin && !dragonfly && !freebsd && !linux && !netbsd && !openbsd && !solaris
+// +build !aix,!darwin,!dragonfly,!freebsd,!linux,!netbsd,!openbsd,!solaris
+
+package checksum
+
+import (
+	"os"
+)
+
+// inode always returns zero on systems without inode numbers, so the cache
+// relies on path, size, and modification time alone
+func inode(info os.FileInfo) uint64 {
+	return 0
+}
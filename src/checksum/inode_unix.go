@@ -0,0 +1,18 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd || solaris
+// +build aix darwin dragonfly freebsd linux netbsd openbsd solaris
+
+package checksum
+
+import (
+	"os"
+	"syscall"
+)
+
+// inode returns the file's inode number, or zero if it isn't known
+func inode(info os.FileInfo) uint64 {
+	var st, ok = info.Sys().(*syscall.Stat_t)
+	if !ok {
+		return 0
+	}
+	return uint64(st.Ino)
+}
@@ -0,0 +1,49 @@
+package checksum
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ReadManifest parses a sha256sum-style manifest: one "<digest>  <path>" line
+// per file, or "<digest> *<path>" for files checksummed in binary mode.
+// Everything after those two separator characters is the path, so paths may
+// start with spaces or "*".  Blank lines and lines starting with "#" are
+// ignored.  Lines starting with a backslash have
+// their paths unescaped the way the coreutils tools write them.  Digests are
+// returned lowercased, keyed by path exactly as the manifest lists it.
+func ReadManifest(r io.Reader) (map[string]string, error) {
+	var sums = make(map[string]string)
+	var s = bufio.NewScanner(r)
+	var lineNum int
+	for s.Scan() {
+		lineNum++
+		var line = strings.TrimRight(s.Text(), "\r")
+		if strings.TrimSpace(line) == "" || line[0] == '#' {
+			continue
+		}
+
+		var escaped = line[0] == '\\'
+		if escaped {
+			line = line[1:]
+		}
+
+		var i = strings.IndexByte(line, ' ')
+		if i < 1 || i+1 >= len(line) || (line[i+1] != ' ' && line[i+1] != '*') {
+			return nil, fmt.Errorf("line %d: expected \"<digest>  <path>\"", lineNum)
+		}
+		var digest, path = line[:i], line[i+2:]
+		if path == "" {
+			return nil, fmt.Errorf("line %d: missing path", lineNum)
+		}
+		if escaped {
+			path = strings.NewReplacer(`\\`, `\`, `\n`, "\n").Replace(path)
+		}
+
+		sums[path] = strings.ToLower(digest)
+	}
+
+	return sums, s.Err()
+}
@@ -0,0 +1,126 @@
+package checksum
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// ReadLimit, if set, slows down the default block write method (and so every
+// Checksum not using a custom BlockWrite) so files aren't read faster than
+// the limiter allows
+var ReadLimit *RateLimiter
+
+// RateLimiter paces a stream of reads to at most Rate bytes per second.  If
+// Window is set, the limit only applies while the current time is within it,
+// and reads run at full speed otherwise.
+type RateLimiter struct {
+	Rate   int64
+	Window *Window
+
+	// now and sleep are replaceable for testing
+	now   func() time.Time
+	sleep func(time.Duration)
+
+	start time.Time
+	bytes int64
+}
+
+// NewRateLimiter returns a limiter allowing rate bytes per second
+func NewRateLimiter(rate int64) *RateLimiter {
+	return &RateLimiter{Rate: rate, now: time.Now, sleep: time.Sleep}
+}
+
+// Wait records that n bytes were read, sleeping as long as necessary to keep
+// the overall rate under the limit.  Up to a second's worth of reads may
+// happen at full speed after an idle period.
+func (l *RateLimiter) Wait(n int) {
+	if l.Rate <= 0 {
+		return
+	}
+	var now = l.now()
+	if l.Window != nil && !l.Window.Contains(now) {
+		l.start = time.Time{}
+		return
+	}
+
+	if l.start.IsZero() || now.After(l.due().Add(time.Second)) {
+		l.start = now
+		l.bytes = 0
+	}
+	l.bytes += int64(n)
+	var d = l.due().Sub(now)
+	if d > 0 {
+		l.sleep(d)
+	}
+}
+
+// due returns when the bytes read so far may be done being read
+func (l *RateLimiter) due() time.Time {
+	return l.start.Add(time.Duration(float64(l.bytes) / float64(l.Rate) * float64(time.Second)))
+}
+
+// Writer wraps w so that every write waits on the limiter
+func (l *RateLimiter) Writer(w io.Writer) io.Writer {
+	return &limitedWriter{w, l}
+}
+
+type limitedWriter struct {
+	w io.Writer
+	l *RateLimiter
+}
+
+func (lw *limitedWriter) Write(p []byte) (int, error) {
+	var n, err = lw.w.Write(p)
+	lw.l.Wait(n)
+	return n, err
+}
+
+// Throttled returns w wrapped by ReadLimit, or w itself if there's no limit.
+// Since io.Copy doesn't read more until a write finishes, throttling the
+// writes from a file throttles reading it.
+func Throttled(w io.Writer) io.Writer {
+	if ReadLimit == nil {
+		return w
+	}
+	return ReadLimit.Writer(w)
+}
+
+// Window is a daily span of local clock time, such as business hours.  If
+// End is before Start, the window runs past midnight.
+type Window struct {
+	Start, End time.Duration
+}
+
+// ParseWindow reads a window in the form "08:00-18:00"
+func ParseWindow(s string) (*Window, error) {
+	var sh, sm, eh, em int
+	var n, err = fmt.Sscanf(s, "%d:%d-%d:%d", &sh, &sm, &eh, &em)
+	if err != nil || n != 4 || sh > 23 || eh > 24 || sm > 59 || em > 59 || sh < 0 || eh < 0 || sm < 0 || em < 0 {
+		return nil, fmt.Errorf("invalid time window %q; expected something like 08:00-18:00", s)
+	}
+	var w = &Window{
+		Start: time.Duration(sh)*time.Hour + time.Duration(sm)*time.Minute,
+		End:   time.Duration(eh)*time.Hour + time.Duration(em)*time.Minute,
+	}
+	if w.End > 24*time.Hour || w.Start == w.End {
+		return nil, fmt.Errorf("invalid time window %q; expected something like 08:00-18:00", s)
+	}
+	return w, nil
+}
+
+// Contains returns true if t's local clock time is within the window
+func (w *Window) Contains(t time.Time) bool {
+	var h, m, s = t.Clock()
+	var tod = time.Duration(h)*time.Hour + time.Duration(m)*time.Minute + time.Duration(s)*time.Second
+	if w.Start < w.End {
+		return tod >= w.Start && tod < w.End
+	}
+	return tod >= w.Start || tod < w.End
+}
+
+// String returns the window in the form ParseWindow reads
+func (w *Window) String() string {
+	return fmt.Sprintf("%02d:%02d-%02d:%02d", int(w.Start.Hours()), int(w.Start.Minutes())%60,
+		int(w.End.Hours()), int(w.End.Minutes())%60)
+}
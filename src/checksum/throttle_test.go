@@ -0,0 +1,105 @@
+package checksum
+
+import (
+	"testing"
+	"time"
+)
+
+// fakeClock lets limiter tests run instantly: sleeping just advances the time
+type fakeClock struct {
+	t     time.Time
+	slept time.Duration
+}
+
+func (c *fakeClock) now() time.Time { return c.t }
+func (c *fakeClock) sleep(d time.Duration) {
+	c.slept += d
+	c.t = c.t.Add(d)
+}
+
+func newTestLimiter(rate int64, start time.Time) (*RateLimiter, *fakeClock) {
+	var c = &fakeClock{t: start}
+	var l = NewRateLimiter(rate)
+	l.now = c.now
+	l.sleep = c.sleep
+	return l, c
+}
+
+func TestRateLimiter(t *testing.T) {
+	var l, c = newTestLimiter(1000, time.Date(2020, 1, 1, 12, 0, 0, 0, time.Local))
+	for i := 0; i < 10; i++ {
+		l.Wait(500)
+	}
+	if c.slept != 5*time.Second {
+		t.Errorf("Expected 5000 bytes at 1000/s to take 5s, slept %s", c.slept)
+	}
+
+	// After a long idle period, reads don't get to "catch up" on the unused
+	// time beyond a one-second burst
+	c.t = c.t.Add(time.Hour)
+	c.slept = 0
+	for i := 0; i < 4; i++ {
+		l.Wait(500)
+	}
+	if c.slept != 2*time.Second {
+		t.Errorf("Expected 2000 bytes after idling to take 2s, slept %s", c.slept)
+	}
+}
+
+func TestRateLimiterWindow(t *testing.T) {
+	var l, c = newTestLimiter(1000, time.Date(2020, 1, 1, 20, 0, 0, 0, time.Local))
+	var err error
+	l.Window, err = ParseWindow("08:00-18:00")
+	if err != nil {
+		t.Fatalf("Unable to parse window: %s", err)
+	}
+
+	l.Wait(10000)
+	if c.slept != 0 {
+		t.Errorf("Expected no throttling after hours, slept %s", c.slept)
+	}
+
+	c.t = time.Date(2020, 1, 2, 9, 0, 0, 0, time.Local)
+	l.Wait(2000)
+	if c.slept != 2*time.Second {
+		t.Errorf("Expected 2000 bytes during business hours to take 2s, slept %s", c.slept)
+	}
+}
+
+func TestParseWindow(t *testing.T) {
+	var at = func(h, m int) time.Time { return time.Date(2020, 1, 1, h, m, 0, 0, time.Local) }
+	var tests = []struct {
+		spec string
+		in   []time.Time
+		out  []time.Time
+	}{
+		{"08:00-18:00", []time.Time{at(8, 0), at(17, 59)}, []time.Time{at(7, 59), at(18, 0)}},
+		{"22:30-06:00", []time.Time{at(23, 0), at(1, 0)}, []time.Time{at(12, 0), at(6, 0)}},
+	}
+	for _, tc := range tests {
+		var w, err = ParseWindow(tc.spec)
+		if err != nil {
+			t.Errorf("Unable to parse %q: %s", tc.spec, err)
+			continue
+		}
+		if w.String() != tc.spec {
+			t.Errorf("Expected %q to round-trip, got %q", tc.spec, w.String())
+		}
+		for _, tm := range tc.in {
+			if !w.Contains(tm) {
+				t.Errorf("Expected %s to contain %s", tc.spec, tm.Format("15:04"))
+			}
+		}
+		for _, tm := range tc.out {
+			if w.Contains(tm) {
+				t.Errorf("Expected %s not to contain %s", tc.spec, tm.Format("15:04"))
+			}
+		}
+	}
+
+	for _, bad := range []string{"", "8-18", "08:00-08:00", "25:00-26:00", "08:00-18:60"} {
+		if _, err := ParseWindow(bad); err == nil {
+			t.Errorf("Expected %q to be rejected", bad)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package checksum
+
+import (
+	"fmt"
+	"hash"
+	"sort"
+	"strings"
+)
+
+// TreeDigest computes a Merkle-style digest of a whole tree from its files'
+// hex digests, keyed by slash-separated path relative to the tree's root.
+// Each directory is hashed from a sorted list of its entries, each entry
+// giving its kind, digest, and name; a file's digest is the one given and a
+// subdirectory's is its own tree digest.  The result depends only on the
+// files' contents and paths, so it's the same no matter what order files were
+// read in, and any change to content, names, or structure changes it.
+//
+// Empty directories have no files to contribute, so they don't affect the
+// digest.
+func TreeDigest(alg string, sums map[string]string) (string, error) {
+	var h, err = NewHash(alg)
+	if err != nil {
+		return "", err
+	}
+
+	var root = newTreeNode()
+	for path, sum := range sums {
+		var parts = strings.Split(path, "/")
+		var n = root
+		for _, dir := range parts[:len(parts)-1] {
+			if n.dirs[dir] == nil {
+				n.dirs[dir] = newTreeNode()
+			}
+			n = n.dirs[dir]
+		}
+		n.files[parts[len(parts)-1]] = strings.ToLower(sum)
+	}
+
+	return root.digest(h), nil
+}
+
+type treeNode struct {
+	files map[string]string
+	dirs  map[string]*treeNode
+}
+
+func newTreeNode() *treeNode {
+	return &treeNode{files: make(map[string]string), dirs: make(map[string]*treeNode)}
+}
+
+// digest hashes the node's entries.  Names are length-prefixed so no name can
+// be mistaken for the end of an entry.
+func (n *treeNode) digest(h hash.Hash) string {
+	var entries []string
+	for name, sum := range n.files {
+		entries = append(entries, fmt.Sprintf("file %s %d:%s\n", sum, len(name), name))
+	}
+	for name, child := range n.dirs {
+		entries = append(entries, fmt.Sprintf("dir %s %d:%s\n", child.digest(h), len(name), name))
+	}
+	sort.Strings(entries)
+
+	h.Reset()
+	for _, e := range entries {
+		h.Write([]byte(e))
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
@@ -0,0 +1,50 @@
+package checksum
+
+import (
+	"testing"
+)
+
+func TestTreeDigest(t *testing.T) {
+	var digest = func(sums map[string]string) string {
+		var d, err = TreeDigest("sha256", sums)
+		if err != nil {
+			t.Fatalf("Unable to compute tree digest: %s", err)
+		}
+		return d
+	}
+
+	var base = map[string]string{"a.txt": "01", "sub/b.txt": "02", "sub/deeper/c.txt": "03"}
+	var d = digest(base)
+
+	// The digest is deterministic no matter how the map is built
+	var same = map[string]string{"sub/deeper/c.txt": "03", "sub/b.txt": "02", "a.txt": "01"}
+	if digest(same) != d {
+		t.Errorf("Expected the same files to produce the same digest")
+	}
+
+	var changes = map[string]map[string]string{
+		"content": {"a.txt": "01", "sub/b.txt": "ff", "sub/deeper/c.txt": "03"},
+		"rename":  {"a.txt": "01", "sub/B.txt": "02", "sub/deeper/c.txt": "03"},
+		"move":    {"a.txt": "01", "b.txt": "02", "sub/deeper/c.txt": "03"},
+		"removal": {"a.txt": "01", "sub/b.txt": "02"},
+	}
+	for name, sums := range changes {
+		if digest(sums) == d {
+			t.Errorf("Expected a %s to change the digest", name)
+		}
+	}
+
+	// A directory and a file with the same name and digest can't collide
+	if digest(map[string]string{"x/y": "01"}) == digest(map[string]string{"x": "01"}) {
+		t.Errorf("Expected a file in a directory to differ from a file in its place")
+	}
+
+	// An empty tree hashes to the digest of nothing
+	if digest(nil) != "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855" {
+		t.Errorf("Unexpected empty tree digest %s", digest(nil))
+	}
+
+	if _, err := TreeDigest("crc32", base); err == nil {
+		t.Errorf("Expected an unknown algorithm to fail")
+	}
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/uoregon-libraries/dark-archive-validator/src/checksum"
+	"github.com/uoregon-libraries/dark-archive-validator/src/rules"
+)
+
+// loadArchiveIndex reads the given manifests of already-archived content into
+// a single index.  Locations are made absolute so the report says exactly
+// where each file's content is preserved.
+func loadArchiveIndex(fnames []string) (*rules.ArchiveIndex, error) {
+	var idx = rules.NewArchiveIndex()
+	for _, fname := range fnames {
+		var err = addArchiveManifest(idx, fname)
+		if err != nil {
+			return nil, fmt.Errorf("Unable to read archive index %s: %s", fname, err)
+		}
+	}
+	return idx, nil
+}
+
+func addArchiveManifest(idx *rules.ArchiveIndex, fname string) error {
+	var f, err = os.Open(fname)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var manifest map[string]string
+	manifest, err = checksum.ReadManifest(f)
+	if err != nil {
+		return err
+	}
+
+	var alg string
+	alg, err = manifestAlgorithm(fname, manifest)
+	if err != nil {
+		return err
+	}
+	fname, err = filepath.Abs(fname)
+	if err != nil {
+		return err
+	}
+	return idx.AddManifest(alg, fname, manifest)
+}
@@ -0,0 +1,259 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/uoregon-libraries/dark-archive-validator/src/checksum"
+)
+
+// bagInfoField is one "Label: Value" line of bag-info.txt
+type bagInfoField struct {
+	Label string
+	Value string
+}
+
+// reservedBagInfo lists the bag-info.txt labels we always compute ourselves
+var reservedBagInfo = []string{"Bagging-Date", "Bag-Software-Agent", "Payload-Oxum"}
+
+// parseBagInfo turns --bag-info values ("Label: Value") into fields
+func parseBagInfo(list []string) ([]bagInfoField, error) {
+	var fields []bagInfoField
+	for _, item := range list {
+		var parts = strings.SplitN(item, ":", 2)
+		var label = strings.TrimSpace(parts[0])
+		if len(parts) != 2 || label == "" {
+			return nil, fmt.Errorf("bag-info field %q must be in the form \"Label: Value\"", item)
+		}
+		for _, reserved := range reservedBagInfo {
+			if strings.EqualFold(label, reserved) {
+				return nil, fmt.Errorf("bag-info field %q is computed automatically", reserved)
+			}
+		}
+		fields = append(fields, bagInfoField{label, strings.TrimSpace(parts[1])})
+	}
+	return fields, nil
+}
+
+// checkBagDir makes sure a bag can be created at dir: it must not be inside
+// the tree being bagged, and must be empty if it exists
+func checkBagDir(dir string) error {
+	var abs, err = filepath.Abs(dir)
+	if err != nil {
+		return err
+	}
+	var rel string
+	rel, err = filepath.Rel(rootPath, abs)
+	if err == nil && !strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("%s is inside the tree being validated", dir)
+	}
+
+	var entries []os.FileInfo
+	entries, err = ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if len(entries) > 0 {
+		return fmt.Errorf("%s is not empty", dir)
+	}
+	return nil
+}
+
+// writeBag copies every checksummed file into dir/data and writes the BagIt
+// 1.0 tag files.  Payload manifests use the digests computed during
+// validation rather than rehashing; each file's main checksum is recomputed
+// as it's copied, though, so a file changed since validation can't slip into
+// the bag.
+//
+// The bag is built in a temporary directory beside dir and only renamed into
+// place once it's complete, so a failed run never leaves a partial bag.
+func writeBag(dir string, r *Report, info []bagInfoField) error {
+	var parent = filepath.Dir(filepath.Clean(dir))
+	var err = os.MkdirAll(parent, 0755)
+	if err != nil {
+		return err
+	}
+	var tmp string
+	tmp, err = ioutil.TempDir(parent, "."+filepath.Base(dir)+".partial")
+	if err != nil {
+		return err
+	}
+
+	err = buildBag(tmp, r, info)
+	if err == nil {
+		err = os.Chmod(tmp, 0755)
+	}
+	if err == nil {
+		// checkBagDir already ensured dir is empty if it exists
+		err = os.Remove(dir)
+		if os.IsNotExist(err) {
+			err = nil
+		}
+	}
+	if err == nil {
+		err = os.Rename(tmp, dir)
+	}
+	if err != nil {
+		os.RemoveAll(tmp)
+	}
+	return err
+}
+
+// buildBag does the work of writeBag, putting the bag's contents in dir
+func buildBag(dir string, r *Report, info []bagInfoField) error {
+	var sums = algorithmSums(r)
+	var alg = opts.Algorithm
+	var paths []string
+	for path := range r.Checksums {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var c, err = checksum.NewAlgorithm(alg)
+	if err == nil {
+		err = os.MkdirAll(filepath.Join(dir, "data"), 0755)
+	}
+	if err != nil {
+		return err
+	}
+	var bytes int64
+	for _, path := range paths {
+		var n int64
+		n, err = copyPayloadFile(filepath.Join(rootPath, path), filepath.Join(dir, "data", path), c, r.Checksums[path])
+		if err != nil {
+			return err
+		}
+		bytes += n
+	}
+
+	// Every algorithm gets a manifest, even if the bag is empty
+	var algs = []string{alg}
+	for _, name := range opts.ExtraHashes {
+		if name != alg {
+			algs = append(algs, name)
+		}
+	}
+	sort.Strings(algs)
+
+	var tagFiles = []string{"bagit.txt", "bag-info.txt"}
+	err = writeTagFile(dir, "bagit.txt", "BagIt-Version: 1.0\nTag-File-Character-Encoding: UTF-8\n")
+	if err == nil {
+		info = append(info,
+			bagInfoField{"Bagging-Date", time.Now().Format("2006-01-02")},
+			bagInfoField{"Bag-Software-Agent", toolName + " " + version},
+			bagInfoField{"Payload-Oxum", fmt.Sprintf("%d.%d", bytes, len(paths))},
+		)
+		err = writeTagFile(dir, "bag-info.txt", bagInfoText(info))
+	}
+	for _, name := range algs {
+		if err != nil {
+			break
+		}
+		var manifest = "manifest-" + name + ".txt"
+		var b strings.Builder
+		for _, path := range paths {
+			b.WriteString(sums[name][path] + "  " + bagPath("data/"+filepath.ToSlash(path)) + "\n")
+		}
+		err = writeTagFile(dir, manifest, b.String())
+		tagFiles = append(tagFiles, manifest)
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeTagManifests(dir, algs, tagFiles)
+}
+
+// copyPayloadFile copies src to dst, preserving its modification time, and
+// returns the number of bytes copied.  The copy fails if src's checksum no
+// longer matches what validation computed.
+func copyPayloadFile(src, dst string, c *checksum.Checksum, expected string) (int64, error) {
+	var info, err = os.Stat(src)
+	if err != nil {
+		return 0, err
+	}
+	err = os.MkdirAll(filepath.Dir(dst), 0755)
+	if err != nil {
+		return 0, err
+	}
+
+	var in, out *os.File
+	in, err = os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	out, err = os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, info.Mode().Perm())
+	if err != nil {
+		return 0, err
+	}
+
+	c.Hash.Reset()
+	var n int64
+	n, err = io.Copy(checksum.Throttled(io.MultiWriter(out, c.Hash)), in)
+	var closeErr = out.Close()
+	if err == nil {
+		err = closeErr
+	}
+	if err == nil && fmt.Sprintf("%x", c.Hash.Sum(nil)) != expected {
+		err = fmt.Errorf("%s changed after it was validated", src)
+	}
+	if err == nil {
+		err = os.Chtimes(dst, info.ModTime(), info.ModTime())
+	}
+	return n, err
+}
+
+// bagPath percent-encodes the characters BagIt requires encoding in manifest
+// paths
+func bagPath(path string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(path)
+}
+
+func bagInfoText(info []bagInfoField) string {
+	var b strings.Builder
+	for _, f := range info {
+		fmt.Fprintf(&b, "%s: %s\n", f.Label, f.Value)
+	}
+	return b.String()
+}
+
+func writeTagFile(dir, name, contents string) error {
+	return ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644)
+}
+
+// writeTagManifests writes a tagmanifest-<algorithm>.txt for each algorithm,
+// covering every tag file written so far
+func writeTagManifests(dir string, algs, tagFiles []string) error {
+	for _, name := range algs {
+		var c, err = checksum.NewAlgorithm(name)
+		if err != nil {
+			return err
+		}
+
+		var b strings.Builder
+		for _, tagFile := range tagFiles {
+			var sum []byte
+			sum, err = c.Sum(filepath.Join(dir, tagFile))
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(&b, "%x  %s\n", sum, tagFile)
+		}
+
+		err = writeTagFile(dir, "tagmanifest-"+name+".txt", b.String())
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
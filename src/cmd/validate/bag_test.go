@@ -0,0 +1,125 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// setupBagTest points rootPath and opts at a new tree holding a couple of
+// files, returning a scratch directory for bags and a report describing the
+// tree.  The returned function restores the globals and removes everything.
+func setupBagTest(t *testing.T) (string, *Report, func()) {
+	var savedRoot, savedOpts = rootPath, opts
+	var scratch, err = ioutil.TempDir("", "bag-test")
+	if err != nil {
+		t.Fatalf("Unable to create scratch dir: %s", err)
+	}
+	var cleanup = func() {
+		rootPath, opts = savedRoot, savedOpts
+		os.RemoveAll(scratch)
+	}
+
+	rootPath = filepath.Join(scratch, "root")
+	opts.Algorithm = "sha256"
+	opts.ExtraHashes = nil
+	var files = map[string]string{"a.txt": "hello\n", filepath.Join("sub", "b c.txt"): "world\n"}
+	for name, contents := range files {
+		var path = filepath.Join(rootPath, name)
+		os.MkdirAll(filepath.Dir(path), 0755)
+		err = ioutil.WriteFile(path, []byte(contents), 0644)
+		if err != nil {
+			cleanup()
+			t.Fatalf("Unable to write %s: %s", path, err)
+		}
+	}
+
+	var r = &Report{
+		ChecksumAlgorithm: "sha256",
+		Checksums: map[string]string{
+			"a.txt":                         "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+			filepath.Join("sub", "b c.txt"): "e258d248fda94c63753607f7c4494ee0fcbe92f1a76bfdac795c9d84101eb317",
+		},
+	}
+	return scratch, r, cleanup
+}
+
+func TestWriteBag(t *testing.T) {
+	var scratch, r, cleanup = setupBagTest(t)
+	defer cleanup()
+
+	var dir = filepath.Join(scratch, "bag")
+	var err = writeBag(dir, r, []bagInfoField{{"Source-Organization", "Test"}})
+	if err != nil {
+		t.Fatalf("Unexpected error writing bag: %s", err)
+	}
+
+	var data []byte
+	data, err = ioutil.ReadFile(filepath.Join(dir, "data", "sub", "b c.txt"))
+	if err != nil || string(data) != "world\n" {
+		t.Errorf("Expected payload file to be copied, got %q (error: %v)", data, err)
+	}
+
+	data, _ = ioutil.ReadFile(filepath.Join(dir, "manifest-sha256.txt"))
+	var expected = r.Checksums["a.txt"] + "  data/a.txt\n" +
+		r.Checksums[filepath.Join("sub", "b c.txt")] + "  data/sub/b c.txt\n"
+	if string(data) != expected {
+		t.Errorf("Expected manifest %q, got %q", expected, data)
+	}
+
+	data, _ = ioutil.ReadFile(filepath.Join(dir, "bag-info.txt"))
+	for _, line := range []string{"Source-Organization: Test\n", "Payload-Oxum: 12.2\n"} {
+		if !strings.Contains(string(data), line) {
+			t.Errorf("Expected bag-info.txt to contain %q, got %q", line, data)
+		}
+	}
+
+	data, _ = ioutil.ReadFile(filepath.Join(dir, "tagmanifest-sha256.txt"))
+	for _, tagFile := range []string{"bagit.txt", "bag-info.txt", "manifest-sha256.txt"} {
+		if !strings.Contains(string(data), "  "+tagFile+"\n") {
+			t.Errorf("Expected tag manifest to cover %s, got %q", tagFile, data)
+		}
+	}
+
+	var entries, _ = ioutil.ReadDir(scratch)
+	if len(entries) != 2 {
+		t.Errorf("Expected only the tree and the bag in %s, got %d entries", scratch, len(entries))
+	}
+}
+
+func TestWriteBagExistingEmptyDir(t *testing.T) {
+	var scratch, r, cleanup = setupBagTest(t)
+	defer cleanup()
+
+	var dir = filepath.Join(scratch, "bag")
+	os.Mkdir(dir, 0755)
+	var err = writeBag(dir, r, nil)
+	if err != nil {
+		t.Fatalf("Unexpected error writing bag into an empty dir: %s", err)
+	}
+	_, err = os.Stat(filepath.Join(dir, "bagit.txt"))
+	if err != nil {
+		t.Errorf("Expected bagit.txt to exist: %s", err)
+	}
+}
+
+func TestWriteBagFailure(t *testing.T) {
+	var scratch, r, cleanup = setupBagTest(t)
+	defer cleanup()
+
+	r.Checksums[filepath.Join("sub", "b c.txt")] = "0000"
+	var dir = filepath.Join(scratch, "bag")
+	var err = writeBag(dir, r, nil)
+	if err == nil {
+		t.Fatalf("Expected an error when a file's checksum doesn't match")
+	}
+
+	var entries, _ = ioutil.ReadDir(scratch)
+	for _, e := range entries {
+		if e.Name() != "root" {
+			t.Errorf("Expected no partial bag to be left behind, found %s", e.Name())
+		}
+	}
+}
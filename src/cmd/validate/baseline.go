@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+
+	"github.com/uoregon-libraries/dark-archive-validator/src/rules"
+)
+
+// BaselineEntry identifies a single accepted finding.  Messages aren't
+// stored, since their wording can change without the finding changing.
+type BaselineEntry struct {
+	Path      string `json:"path"`
+	Validator string `json:"validator"`
+	Code      string `json:"code"`
+}
+
+func newBaselineEntry(path string, f rules.Failure) BaselineEntry {
+	return BaselineEntry{Path: path, Validator: f.V.Name, Code: f.Code()}
+}
+
+// baselineRun identifies one validator's run against one path
+type baselineRun struct {
+	path      string
+	validator string
+}
+
+// baseline holds the findings from a previous run, tracking which of them
+// are still present in the current run.  seen holds the findings which were
+// suppressed, found holds every finding which was present (including
+// critical ones, which are never suppressed), ran records which of the
+// baseline's validators actually ran against the baseline's paths, and
+// walked records every path the run examined.
+type baseline struct {
+	entries map[BaselineEntry]bool
+	seen    map[BaselineEntry]bool
+	found   map[BaselineEntry]bool
+	wanted  map[baselineRun]bool
+	ran     map[baselineRun]bool
+	walked  map[string]bool
+}
+
+// readBaseline loads a baseline file written by writeBaseline
+func readBaseline(fname string) (*baseline, error) {
+	var data, err = ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+
+	var list []BaselineEntry
+	err = json.Unmarshal(data, &list)
+	if err != nil {
+		return nil, err
+	}
+
+	return newBaseline(list), nil
+}
+
+func newBaseline(list []BaselineEntry) *baseline {
+	var b = &baseline{
+		entries: make(map[BaselineEntry]bool),
+		seen:    make(map[BaselineEntry]bool),
+		found:   make(map[BaselineEntry]bool),
+		wanted:  make(map[baselineRun]bool),
+		ran:     make(map[baselineRun]bool),
+		walked:  make(map[string]bool),
+	}
+	for _, e := range list {
+		b.entries[e] = true
+		b.wanted[baselineRun{e.Path, e.Validator}] = true
+	}
+	return b
+}
+
+// filter is an engine FilterFn which removes failures already in the
+// baseline, remembering them so they aren't reported as fixed.  Critical
+// failures are always reported, just as critical validators can't be
+// skipped.
+func (b *baseline) filter(path string, fl []rules.Failure) []rules.Failure {
+	var kept []rules.Failure
+	for _, f := range fl {
+		var e = newBaselineEntry(path, f)
+		if b.entries[e] {
+			b.found[e] = true
+			if f.V.Criticality != rules.CCritical {
+				b.seen[e] = true
+				continue
+			}
+		}
+		kept = append(kept, f)
+	}
+
+	return kept
+}
+
+// recordRun is an engine RanFn which notes when a baseline entry's validator
+// ran against its path, since an entry can only be fixed if it was checked
+func (b *baseline) recordRun(path, validator string) {
+	var r = baselineRun{path, validator}
+	if b.wanted[r] {
+		b.ran[r] = true
+	}
+}
+
+// recordEntry notes that the run examined path, so baseline entries for
+// paths which weren't examined can be known to be gone
+func (b *baseline) recordEntry(path string) {
+	b.walked[path] = true
+}
+
+// suppressed returns the baseline entries which were found again
+func (b *baseline) suppressed() []BaselineEntry {
+	var list []BaselineEntry
+	for e := range b.seen {
+		list = append(list, e)
+	}
+	sortBaselineEntries(list)
+	return list
+}
+
+// fixed returns the baseline entries which no longer fail, including those
+// whose path no longer exists because it was deleted or renamed.  Entries
+// whose validator didn't run on a path which still exists (because it was
+// skipped, or an earlier failure stopped it) can't be known to be fixed, and
+// neither can findings which are now waived, so those aren't listed.
+func (b *baseline) fixed(waived []rules.WaivedFailure) []BaselineEntry {
+	var stillWaived = make(map[BaselineEntry]bool)
+	for _, w := range waived {
+		stillWaived[newBaselineEntry(w.Path, w.Failure)] = true
+	}
+
+	var list = make([]BaselineEntry, 0)
+	for e := range b.entries {
+		if b.found[e] || stillWaived[e] {
+			continue
+		}
+		if b.ran[baselineRun{e.Path, e.Validator}] || !b.walked[e.Path] {
+			list = append(list, e)
+		}
+	}
+	sortBaselineEntries(list)
+	return list
+}
+
+func sortBaselineEntries(list []BaselineEntry) {
+	sort.Slice(list, func(i, j int) bool {
+		var a, b = list[i], list[j]
+		if a.Path != b.Path {
+			return a.Path < b.Path
+		}
+		if a.Validator != b.Validator {
+			return a.Validator < b.Validator
+		}
+		return a.Code < b.Code
+	})
+}
+
+// writeBaseline saves every finding from this run, including those which a
+// previous baseline suppressed, so the file can simply be refreshed
+func writeBaseline(fname string, b *baseline) error {
+	var list = make([]BaselineEntry, 0)
+	for _, fvf := range fileValidationFailures {
+		for _, f := range fvf.Failures {
+			list = append(list, newBaselineEntry(fvf.Filepath, f))
+		}
+	}
+	if b != nil {
+		list = append(list, b.suppressed()...)
+	}
+	sortBaselineEntries(list)
+
+	var data, err = json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(fname, append(data, '\n'), 0666)
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/uoregon-libraries/dark-archive-validator/src/rules"
+)
+
+func TestBaselineFilter(t *testing.T) {
+	var b = newBaseline([]BaselineEntry{
+		{Path: "a", Validator: "no-spaces", Code: "no-spaces"},
+		{Path: "a", Validator: "broken-file", Code: "broken-file"},
+	})
+
+	var fl = []rules.Failure{
+		{V: rules.Validator{Name: "no-spaces", Criticality: rules.CNormal}, E: errors.New("space")},
+		{V: rules.Validator{Name: "broken-file", Criticality: rules.CCritical}, E: errors.New("broken")},
+	}
+	var kept = b.filter("a", fl)
+	if len(kept) != 1 || kept[0].V.Name != "broken-file" {
+		t.Errorf("Expected the critical failure to be reported despite the baseline, got %#v", kept)
+	}
+	if len(b.suppressed()) != 1 {
+		t.Errorf("Expected one suppressed finding, got %#v", b.suppressed())
+	}
+	b.recordRun("a", "no-spaces")
+	b.recordRun("a", "broken-file")
+	if len(b.fixed(nil)) != 0 {
+		t.Errorf("Expected nothing to be fixed, got %#v", b.fixed(nil))
+	}
+}
+
+func TestBaselineFixed(t *testing.T) {
+	var b = newBaseline([]BaselineEntry{
+		{Path: "ran", Validator: "no-spaces", Code: "no-spaces"},
+		{Path: "skipped", Validator: "no-spaces", Code: "no-spaces"},
+		{Path: "ran", Validator: "has-extension", Code: "has-extension"},
+	})
+
+	// "skipped" was never checked, and has-extension didn't run on "ran"
+	b.recordEntry("ran")
+	b.recordEntry("skipped")
+	b.recordRun("ran", "no-spaces")
+	b.recordRun("other", "no-spaces")
+
+	var fixed = b.fixed(nil)
+	if len(fixed) != 1 || fixed[0].Path != "ran" || fixed[0].Validator != "no-spaces" {
+		t.Errorf("Expected only no-spaces on \"ran\" to be fixed, got %#v", fixed)
+	}
+}
+
+func TestBaselineFixedMissingPaths(t *testing.T) {
+	var b = newBaseline([]BaselineEntry{
+		{Path: "deleted", Validator: "no-spaces", Code: "no-spaces"},
+		{Path: "old name", Validator: "no-spaces", Code: "no-spaces"},
+	})
+
+	// "deleted" is gone, and "old name" was renamed to "new name", so neither
+	// was walked
+	b.recordEntry("new name")
+	b.recordRun("new name", "no-spaces")
+
+	var fixed = b.fixed(nil)
+	if len(fixed) != 2 || fixed[0].Path != "deleted" || fixed[1].Path != "old name" {
+		t.Errorf("Expected findings on deleted and renamed paths to be fixed, got %#v", fixed)
+	}
+}
+
+func TestBaselineFixedWaived(t *testing.T) {
+	var b = newBaseline([]BaselineEntry{{Path: "a", Validator: "no-spaces", Code: "no-spaces"}})
+	b.recordEntry("a")
+	b.recordRun("a", "no-spaces")
+
+	var f = rules.Failure{V: rules.Validator{Name: "no-spaces"}, E: errors.New("space")}
+	var fixed = b.fixed([]rules.WaivedFailure{{Path: "a", Failure: f}})
+	if len(fixed) != 0 {
+		t.Errorf("Expected a waived finding not to be fixed, got %#v", fixed)
+	}
+}
@@ -1,7 +1,6 @@
 package main
 
 import (
-	"crypto/sha256"
 	"fmt"
 	"log"
 	"os"
@@ -15,23 +14,56 @@ import (
 
 var parser *flags.Parser
 var opts struct {
-	SkipList       []string `short:"s" long:"skip" description:"Skip a particular validator.  Cannot be used to skip critical validations.  Can be repeated to skip multiple validations."`
-	Quick          bool     `long:"quick" description:"Skip checksum and lowest-criticality validators"`
-	ListValidators bool     `short:"l" long:"list-validators" description:"List all validators this command would have run"`
-	SHAOutput      string   `short:"o" long:"sha-output" description:"Filename for writing all files' SHA256 hashes"`
+	SkipList         []string `short:"s" long:"skip" description:"Skip a particular validator.  Cannot be used to skip critical validations.  Can be repeated to skip multiple validations."`
+	Quick            bool     `long:"quick" description:"Skip checksum and lowest-criticality validators"`
+	ListValidators   bool     `short:"l" long:"list-validators" description:"List all validators this command would have run"`
+	Algorithm        string   `long:"algorithm" description:"Hash algorithm used for duplicate content detection and --checksum-output" choice:"md5" choice:"sha1" choice:"sha256" choice:"sha512" choice:"blake2b" default:"sha256"`
+	ChecksumOutput   string   `short:"o" long:"checksum-output" description:"Filename for writing all files' --algorithm checksums"`
+	SHAOutput        string   `long:"sha-output" hidden:"true" description:"Deprecated alias for --checksum-output"`
+	ExtraHashes      []string `long:"extra-hash" description:"Also compute this hash for every file, from the same read as the --algorithm hash.  Can be repeated." choice:"md5" choice:"sha1" choice:"sha256" choice:"sha512" choice:"blake2b"`
+	ChecksumCache    string   `long:"checksum-cache" description:"Cache file of checksums from previous runs; files whose path, size, modification time, and inode are unchanged aren't read again, and entries for files no longer in the tree are dropped"`
+	Rehash           bool     `long:"rehash" description:"Ignore the --checksum-cache entries and read every file, updating the cache"`
+	MaxReadRate      string   `long:"max-read-rate" description:"Limit how fast files are read for checksumming, in bytes per second with an optional K, M, or G suffix (e.g., 50M), so production storage isn't saturated"`
+	ThrottleHours    string   `long:"throttle-hours" description:"Only apply --max-read-rate during this daily span of local time, such as 08:00-18:00, reading at full speed otherwise"`
+	DupeStrategy     string   `long:"dupe-strategy" description:"How no-duped-content finds duplicates: full checksums every file; size-first only reads files sharing a size with another file, and only fully checksums those whose first and last blocks also match.  Every file is still fully checksummed when --checksum-output, --manifest-dir, --extra-hash, --bag, --inventory, or --format=premis needs the checksums." choice:"full" choice:"size-first" default:"full"`
+	ArchiveIndex     []string `long:"archive-index" description:"Manifest of content already in the dark archive, such as a bag's manifest-sha256.txt or a --checksum-output file; files with the same content are reported along with where it's preserved.  The algorithm is taken from the filename or the digest length.  Can be repeated."`
+	ManifestDir      string   `long:"manifest-dir" description:"Write a manifest-<algorithm>.txt file of relative paths to this directory for --algorithm and each --extra-hash, plus a tree-digest.txt when every file was checksummed"`
+	BagIt            bool     `long:"bagit" description:"Validate the path as a BagIt bag: check its tag files, manifests, Payload-Oxum, and checksums, and apply the naming rules only to data/"`
+	Bag              string   `long:"bag" description:"If validation passes (see --fail-on), copy the tree into a new BagIt bag in this directory, using the --algorithm and --extra-hash digests for its manifests"`
+	BagInfo          []string `long:"bag-info" description:"Add a \"Label: Value\" field to the bag's bag-info.txt.  Can be repeated."`
+	Format           string   `short:"f" long:"format" description:"Report format; the run summary is printed to stderr for tsv and embedded in the report otherwise" choice:"tsv" choice:"json" choice:"tree" choice:"xlsx" choice:"premis" default:"tsv"`
+	Template         string   `long:"template" description:"Render the report with this Go template instead of using --format; files named *.html or *.html.tmpl are rendered as HTML"`
+	Inventory        string   `long:"inventory" description:"Write a TSV listing every path examined, passing or failing, with its type, size, modification time, and checksum (if computed)"`
+	DuplicatesReport string   `long:"duplicates-report" description:"Write a TSV listing every set of files with identical content, with each file's size and the bytes reclaimable by keeping one copy, largest waste first"`
+	ProfileRules     bool     `long:"profile-rules" description:"Print a table of time spent in each validator to stderr, and include it in structured reports"`
+	ReportFile       string   `long:"report-file" description:"Write the report to this file instead of stdout"`
+	SignKey          string   `long:"sign-key" description:"Sign the report with this PEM ed25519 private key, writing the signature to the report file plus .sig (requires --report-file)"`
+	TreeCollapse     int      `long:"tree-collapse" description:"In the tree format, collapse sibling entries with identical failures when there are at least this many (0 disables)" default:"5"`
+	Waivers          string   `long:"waivers" description:"JSON file of per-path waivers; waived failures are listed separately, and expired waivers are reported as failures"`
+	Baseline         string   `long:"baseline" description:"Suppress findings listed in this baseline file, reporting only new findings and those which were fixed"`
+	WriteBaseline    string   `long:"write-baseline" description:"Write all of this run's findings to a baseline file for use with --baseline"`
+	Lang             string   `long:"lang" description:"Language for validator messages; anything other than English requires a <lang>.txt catalog in the locale directory" default:"en"`
+	LocaleDir        string   `long:"locale-dir" description:"Directory holding message catalogs for --lang (default: the locales directory next to the executable or its parent, so both an installed binary and bin/validate in a checkout find the catalogs)"`
+	FailOn           string   `long:"fail-on" description:"Lowest criticality which causes a non-zero exit code" choice:"critical" choice:"high" choice:"normal" choice:"low" default:"low"`
 }
 
 func usage(err error) {
+	subcommandUsage(parser, err)
+}
+
+// subcommandUsage reports the error, if any, and prints help for the given
+// parser before exiting
+func subcommandUsage(p *flags.Parser, err error) {
 	var status int
 	if flagsErr, ok := err.(*flags.Error); ok && flagsErr.Type == flags.ErrHelp {
 		err = nil
 	}
 	if err != nil {
 		os.Stderr.Write([]byte("ERROR: " + err.Error() + "\n\n"))
-		status = 1
+		status = exitError
 	}
 
-	parser.WriteHelp(os.Stderr)
+	p.WriteHelp(os.Stderr)
 	os.Exit(status)
 }
 
@@ -76,37 +108,199 @@ func skipUnimportantValidators() {
 	}
 }
 
+// registerChecksumValidator sets up duplicate content detection with the
+// requested strategy.  Size-first is only used when nothing else needs every
+// file's checksum.
+func registerChecksumValidator(c *checksum.Checksum) {
+	var needSums = opts.ChecksumOutput != "" || opts.ManifestDir != "" || len(opts.ExtraHashes) > 0 ||
+		opts.Bag != "" || opts.Inventory != "" || opts.Format == "premis"
+	if opts.DupeStrategy == "size-first" && !needSums {
+		// The engine's TraverseFn isn't final until --bagit is processed
+		var traverse = func(root string, fn filepath.WalkFunc) error {
+			return engine.TraverseFn(root, fn)
+		}
+		rules.RegisterSizeFirstChecksumValidator(rootPath, c, checksums, sumStore, traverse)
+		sizeFirstChecksums = true
+		return
+	}
+	rules.RegisterChecksumValidator(rootPath, c, checksums, digests, sumStore)
+}
+
 func processCLI() {
 	parser = flags.NewParser(&opts, flags.HelpFlag)
 	parser.Usage = "[OPTIONS] <path to validate>"
+	parser.LongDescription = "Validates all files under the given path.  Exits 0 if " +
+		"nothing at or above the --fail-on criticality failed, 1 on usage or runtime " +
+		"errors, and otherwise with the most severe criticality found: 2 for low, " +
+		"3 for normal, 4 for high, and 5 for critical.\n\n" +
+		"Other commands, each with its own -h help: " +
+		"diff (compare two JSON reports), " +
+		"verify (compare a tree to a checksum manifest), " +
+		"verify-report (check a report signed with --sign-key)."
 	var more, err = parser.Parse()
 	if err != nil {
 		usage(err)
 	}
 
+	failOn, err = rules.ParseCriticality(opts.FailOn)
+	if err != nil {
+		usage(err)
+	}
+
 	if len(more) > 0 {
 		getRootPath(more[0])
 	}
-	rules.RegisterChecksumValidator(rootPath, checksum.New(sha256.New()), checksums)
+	var c *checksum.Checksum
+	c, err = checksum.NewAlgorithm(opts.Algorithm)
+	if err != nil {
+		usage(err)
+	}
+	for _, alg := range opts.ExtraHashes {
+		if alg != opts.Algorithm {
+			c.AddAlgorithm(alg)
+		}
+	}
+	if opts.Rehash && opts.ChecksumCache == "" {
+		usage(fmt.Errorf("--rehash requires --checksum-cache"))
+	}
+	err = setReadLimit(opts.MaxReadRate, opts.ThrottleHours)
+	if err != nil {
+		usage(err)
+	}
+	if opts.ChecksumCache != "" {
+		checksumCache, err = checksum.LoadCache(opts.ChecksumCache)
+		if err != nil {
+			usage(fmt.Errorf("Unable to read checksum cache %s: %s", opts.ChecksumCache, err))
+		}
+		checksumCache.Rehash = opts.Rehash
+	}
+	sumStore = rules.NewSumStore(checksumCache)
 
-	if opts.SHAOutput != "" {
+	if opts.ChecksumOutput == "" {
+		opts.ChecksumOutput = opts.SHAOutput
+	}
+	registerChecksumValidator(c)
+
+	if len(opts.ArchiveIndex) > 0 {
+		var idx, err = loadArchiveIndex(opts.ArchiveIndex)
+		if err != nil {
+			usage(err)
+		}
+		rules.RegisterArchiveIndexValidator(rootPath, idx, sumStore)
+	}
+	if opts.ChecksumOutput != "" {
 		// Make sure the given file can be created and written
-		var _, err = os.OpenFile(opts.SHAOutput, os.O_RDWR|os.O_CREATE, 0666)
+		var _, err = os.OpenFile(opts.ChecksumOutput, os.O_RDWR|os.O_CREATE, 0666)
+		if err != nil {
+			usage(fmt.Errorf("Unable to write to %s", opts.ChecksumOutput))
+		}
+	}
+	if opts.ManifestDir != "" {
+		var err = checkManifestDir(opts.ManifestDir)
 		if err != nil {
-			usage(fmt.Errorf("Unable to write to %s", opts.SHAOutput))
+			usage(fmt.Errorf("Unable to write manifests to %s: %s", opts.ManifestDir, err))
 		}
 	}
 
 	// --quick skips non-critical validators and the very slow checksumming
 	// validator
 	if opts.Quick {
-		if opts.SHAOutput != "" {
-			usage(fmt.Errorf("Cannot combine --quick and --sha-output"))
+		if opts.ChecksumOutput != "" || opts.ManifestDir != "" || len(opts.ExtraHashes) > 0 || opts.Bag != "" ||
+			len(opts.ArchiveIndex) > 0 || opts.DuplicatesReport != "" {
+			usage(fmt.Errorf("Cannot combine --quick with --checksum-output, --manifest-dir, --extra-hash, --bag, --archive-index, or --duplicates-report"))
 		}
 		skipUnimportantValidators()
 		opts.SkipList = append(opts.SkipList, "no-duped-content")
 	}
 
+	if opts.BagIt {
+		bagCheck = rules.RegisterBagValidator(rootPath, sumStore)
+		engine.TraverseFn = bagCheck.TraverseFn
+	}
+
+	// Every path walked is marked as seen in the checksum cache, so entries
+	// for files which have since been deleted or moved can be pruned
+	if checksumCache != nil {
+		var walk = engine.TraverseFn
+		engine.TraverseFn = func(root string, fn filepath.WalkFunc) error {
+			return walk(root, func(path string, info os.FileInfo, err error) error {
+				checksumCache.Keep(path)
+				return fn(path, info, err)
+			})
+		}
+	}
+
+	if opts.Bag != "" {
+		for _, name := range opts.SkipList {
+			if name == "no-duped-content" {
+				usage(fmt.Errorf("--bag needs checksums, so no-duped-content can't be skipped"))
+			}
+		}
+		bagInfo, err = parseBagInfo(opts.BagInfo)
+		if err == nil {
+			err = checkBagDir(opts.Bag)
+		}
+		if err != nil {
+			usage(fmt.Errorf("Unable to create bag: %s", err))
+		}
+	}
+
+	if inventoryWanted() {
+		engine.EntryFn = recordInventory
+	}
+
+	if opts.Template != "" {
+		reportTmpl, err = parseTemplate(opts.Template)
+		if err != nil {
+			usage(fmt.Errorf("Unable to parse template: %s", err))
+		}
+	}
+
+	if opts.Format == "xlsx" && opts.ReportFile == "" {
+		usage(fmt.Errorf("--format=xlsx requires --report-file"))
+	}
+
+	if opts.SignKey != "" {
+		if opts.ReportFile == "" {
+			usage(fmt.Errorf("--sign-key requires --report-file"))
+		}
+		signingKey, err = readPrivateKey(opts.SignKey)
+		if err != nil {
+			usage(fmt.Errorf("Unable to read signing key: %s", err))
+		}
+	}
+
+	if opts.Lang != "en" {
+		var catalog = findCatalog(opts.LocaleDir, opts.Lang)
+		err = rules.LoadMessages(catalog)
+		if err != nil {
+			usage(fmt.Errorf("Unable to load messages for --lang=%s: %s", opts.Lang, err))
+		}
+	}
+
+	if opts.Waivers != "" {
+		engine.Waivers, err = rules.LoadWaivers(opts.Waivers)
+		if err != nil {
+			usage(fmt.Errorf("Unable to read waivers %s: %s", opts.Waivers, err))
+		}
+	}
+
+	if opts.Baseline != "" {
+		acceptedFindings, err = readBaseline(opts.Baseline)
+		if err != nil {
+			usage(fmt.Errorf("Unable to read baseline %s: %s", opts.Baseline, err))
+		}
+		engine.FilterFn = acceptedFindings.filter
+		engine.RanFn = acceptedFindings.recordRun
+		var next = engine.EntryFn
+		engine.EntryFn = func(path string, info os.FileInfo, fl []rules.Failure) {
+			acceptedFindings.recordEntry(path)
+			if next != nil {
+				next(path, info, fl)
+			}
+		}
+	}
+
 	// Check for skips so we can verify those quickly
 	var invalids = processSkipList()
 	if len(invalids) != 0 {
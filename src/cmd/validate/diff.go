@@ -0,0 +1,263 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/jessevdk/go-flags"
+)
+
+var diffOpts struct{}
+
+// findingKey identifies a finding independently of its path and message
+type findingKey struct {
+	Validator string
+	Code      string
+}
+
+// pathDiff holds the comparison of one path's findings between two reports.
+// OldPath is only set when the path was renamed.  Waived holds old findings
+// which the new report waived rather than resolved.
+type pathDiff struct {
+	Path      string
+	OldPath   string
+	Resolved  []ReportFailure
+	Waived    []ReportFailure
+	New       []ReportFailure
+	Unchanged []ReportFailure
+}
+
+// readReport loads a JSON report written by "validate --format=json"
+func readReport(fname string) (*Report, error) {
+	var f, err = os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r Report
+	err = json.NewDecoder(f).Decode(&r)
+	if err != nil {
+		return nil, fmt.Errorf("%s is not a valid JSON report: %s", fname, err)
+	}
+	return &r, nil
+}
+
+// failuresByPath groups a report's failures by path
+func failuresByPath(r *Report) map[string][]ReportFailure {
+	var m = make(map[string][]ReportFailure)
+	for _, f := range r.Failures {
+		m[f.Path] = append(m[f.Path], f)
+	}
+	return m
+}
+
+// waivedByPath groups a report's waived findings by path
+func waivedByPath(r *Report) map[string][]ReportFailure {
+	var m = make(map[string][]ReportFailure)
+	for _, w := range r.Waived {
+		m[w.Path] = append(m[w.Path], w.ReportFailure)
+	}
+	return m
+}
+
+// checksumAlgorithm returns the algorithm used for a report's checksums.
+// Reports written before --algorithm existed always used SHA256.
+func checksumAlgorithm(r *Report) string {
+	if r.ChecksumAlgorithm == "" {
+		return "sha256"
+	}
+	return r.ChecksumAlgorithm
+}
+
+// findRenames looks for paths which failed in the old report and no longer
+// exist, but whose content shows up under a new path, returning a map of new
+// path to old path.  This only works when both reports have checksums from
+// the same algorithm.  If several missing paths match the same new path, the
+// first in sorted order is taken as the rename.
+func findRenames(oldR, newR *Report, oldFailures map[string][]ReportFailure) map[string]string {
+	var renames = make(map[string]string)
+	if len(oldR.Checksums) == 0 || len(newR.Checksums) == 0 {
+		return renames
+	}
+	if checksumAlgorithm(oldR) != checksumAlgorithm(newR) {
+		return renames
+	}
+
+	var newPathsBySum = make(map[string][]string)
+	for path, sum := range newR.Checksums {
+		if _, existed := oldR.Checksums[path]; !existed {
+			newPathsBySum[sum] = append(newPathsBySum[sum], path)
+		}
+	}
+
+	var oldPaths []string
+	for oldPath := range oldFailures {
+		oldPaths = append(oldPaths, oldPath)
+	}
+	sort.Strings(oldPaths)
+
+	for _, oldPath := range oldPaths {
+		if _, stillExists := newR.Checksums[oldPath]; stillExists {
+			continue
+		}
+		var sum, ok = oldR.Checksums[oldPath]
+		if !ok {
+			continue
+		}
+
+		// Only an unambiguous match counts as a rename
+		var candidates = newPathsBySum[sum]
+		if len(candidates) == 1 && renames[candidates[0]] == "" {
+			renames[candidates[0]] = oldPath
+		}
+	}
+
+	return renames
+}
+
+// compareFailures splits two lists of a single path's failures into resolved,
+// waived, new, and unchanged findings.  An old finding missing from newList
+// is only resolved if it isn't in newWaived.
+func compareFailures(pd *pathDiff, oldList, newList, newWaived []ReportFailure) {
+	var oldKeys = make(map[findingKey]bool)
+	for _, f := range oldList {
+		oldKeys[findingKey{f.Validator, f.Code}] = true
+	}
+	var newKeys = make(map[findingKey]bool)
+	for _, f := range newList {
+		newKeys[findingKey{f.Validator, f.Code}] = true
+	}
+
+	var waivedKeys = make(map[findingKey]bool)
+	for _, f := range newWaived {
+		waivedKeys[findingKey{f.Validator, f.Code}] = true
+	}
+
+	for _, f := range oldList {
+		var key = findingKey{f.Validator, f.Code}
+		switch {
+		case newKeys[key]:
+		case waivedKeys[key]:
+			pd.Waived = append(pd.Waived, f)
+		default:
+			pd.Resolved = append(pd.Resolved, f)
+		}
+	}
+	for _, f := range newList {
+		if oldKeys[findingKey{f.Validator, f.Code}] {
+			pd.Unchanged = append(pd.Unchanged, f)
+		} else {
+			pd.New = append(pd.New, f)
+		}
+	}
+}
+
+// diffReports compares the failures of two reports path by path
+func diffReports(oldR, newR *Report) []*pathDiff {
+	var oldFailures = failuresByPath(oldR)
+	var newFailures = failuresByPath(newR)
+	var newWaived = waivedByPath(newR)
+	var renames = findRenames(oldR, newR, oldFailures)
+	var renamedFrom = make(map[string]bool)
+	for _, oldPath := range renames {
+		renamedFrom[oldPath] = true
+	}
+
+	var paths = make(map[string]bool)
+	for path := range oldFailures {
+		if !renamedFrom[path] {
+			paths[path] = true
+		}
+	}
+	for path := range newFailures {
+		paths[path] = true
+	}
+	for path := range renames {
+		paths[path] = true
+	}
+
+	var diffs []*pathDiff
+	for path := range paths {
+		var pd = &pathDiff{Path: path}
+		var oldPath = path
+		if renames[path] != "" {
+			oldPath = renames[path]
+			pd.OldPath = oldPath
+		}
+		compareFailures(pd, oldFailures[oldPath], newFailures[path], newWaived[path])
+		diffs = append(diffs, pd)
+	}
+
+	sort.Slice(diffs, func(i, j int) bool { return diffs[i].Path < diffs[j].Path })
+	return diffs
+}
+
+// printDiff writes the comparison grouped by path, followed by totals.  It
+// returns true if anything regressed.
+func printDiff(w io.Writer, diffs []*pathDiff) (regressed bool) {
+	var resolved, waived, added, unchanged int
+	for _, pd := range diffs {
+		if pd.OldPath != "" {
+			fmt.Fprintf(w, "%#v (renamed from %#v)\n", pd.Path, pd.OldPath)
+		} else {
+			fmt.Fprintf(w, "%#v\n", pd.Path)
+		}
+		for _, f := range pd.New {
+			fmt.Fprintf(w, "  new:       %s (%s) %s\n", f.Validator, f.Code, f.Message)
+		}
+		for _, f := range pd.Resolved {
+			fmt.Fprintf(w, "  resolved:  %s (%s) %s\n", f.Validator, f.Code, f.Message)
+		}
+		for _, f := range pd.Waived {
+			fmt.Fprintf(w, "  waived:    %s (%s) %s\n", f.Validator, f.Code, f.Message)
+		}
+		for _, f := range pd.Unchanged {
+			fmt.Fprintf(w, "  unchanged: %s (%s) %s\n", f.Validator, f.Code, f.Message)
+		}
+
+		resolved += len(pd.Resolved)
+		waived += len(pd.Waived)
+		added += len(pd.New)
+		unchanged += len(pd.Unchanged)
+	}
+
+	fmt.Fprintf(w, "\n%d resolved, %d waived, %d new, %d unchanged\n", resolved, waived, added, unchanged)
+	return added > 0
+}
+
+// runDiff implements "validate diff", comparing two JSON reports
+func runDiff(args []string) int {
+	var p = flags.NewParser(&diffOpts, flags.HelpFlag)
+	p.Usage = "diff [OPTIONS] <old report.json> <new report.json>"
+	p.LongDescription = "Compares the failures in two JSON reports, listing resolved, " +
+		"waived, new, and unchanged findings per path.  Renamed files are matched by checksum " +
+		"when both reports have checksums.  Exits 0 if nothing regressed, 1 on usage " +
+		"or runtime errors, and 2 if there are new findings."
+
+	var more, err = p.ParseArgs(args)
+	if err == nil && len(more) != 2 {
+		err = fmt.Errorf("must specify exactly two reports to compare")
+	}
+	if err != nil {
+		subcommandUsage(p, err)
+	}
+
+	var oldR, newR *Report
+	oldR, err = readReport(more[0])
+	if err == nil {
+		newR, err = readReport(more[1])
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		return exitError
+	}
+
+	if printDiff(os.Stdout, diffReports(oldR, newR)) {
+		return exitRegressed
+	}
+	return exitOK
+}
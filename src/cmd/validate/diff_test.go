@@ -0,0 +1,109 @@
+package main
+
+import (
+	"os"
+	"testing"
+)
+
+func Example_diffReports() {
+	var oldR = &Report{
+		Checksums: map[string]string{"a b.txt": "1111", "fixed.txt": "2222", "same.txt": "3333"},
+		Failures: []ReportFailure{
+			{Path: "a b.txt", Validator: "no-spaces", Code: "space", Message: "has spaces"},
+			{Path: "fixed.txt", Validator: "no-duped-content", Code: "duplicate", Message: "is a duplicate"},
+			{Path: "same.txt", Validator: "no-spaces", Code: "space", Message: "has spaces"},
+		},
+	}
+	var newR = &Report{
+		Checksums: map[string]string{"a_b.txt": "1111", "fixed.txt": "2222", "same.txt": "3333", "new.txt": "4444"},
+		Failures: []ReportFailure{
+			{Path: "same.txt", Validator: "no-spaces", Code: "space", Message: "has spaces"},
+			{Path: "new.txt", Validator: "no-bad-chars", Code: "bad-char", Message: "has bad characters"},
+		},
+	}
+
+	printDiff(os.Stdout, diffReports(oldR, newR))
+
+	// Output:
+	// "a_b.txt" (renamed from "a b.txt")
+	//   resolved:  no-spaces (space) has spaces
+	// "fixed.txt"
+	//   resolved:  no-duped-content (duplicate) is a duplicate
+	// "new.txt"
+	//   new:       no-bad-chars (bad-char) has bad characters
+	// "same.txt"
+	//   unchanged: no-spaces (space) has spaces
+	//
+	// 2 resolved, 0 waived, 1 new, 1 unchanged
+}
+
+func Example_diffReportsAmbiguousRename() {
+	// Both missing paths had the same content, which now lives in one new
+	// file, so the first missing path in sorted order is taken as the rename
+	var oldR = &Report{
+		Checksums: map[string]string{"x 1.txt": "1111", "x 2.txt": "1111"},
+		Failures: []ReportFailure{
+			{Path: "x 1.txt", Validator: "no-spaces", Code: "space", Message: "has spaces"},
+			{Path: "x 2.txt", Validator: "no-spaces", Code: "space", Message: "has spaces"},
+		},
+	}
+	var newR = &Report{Checksums: map[string]string{"x.txt": "1111"}}
+
+	printDiff(os.Stdout, diffReports(oldR, newR))
+
+	// Output:
+	// "x 2.txt"
+	//   resolved:  no-spaces (space) has spaces
+	// "x.txt" (renamed from "x 1.txt")
+	//   resolved:  no-spaces (space) has spaces
+	//
+	// 2 resolved, 0 waived, 0 new, 0 unchanged
+}
+
+func TestFindRenames(t *testing.T) {
+	var oldR = &Report{Checksums: map[string]string{"gone": "1111", "dupe-gone": "2222", "kept": "3333"}}
+	var newR = &Report{Checksums: map[string]string{"moved": "1111", "copy1": "2222", "copy2": "2222", "kept": "3333"}}
+	var failures = map[string][]ReportFailure{"gone": nil, "dupe-gone": nil, "kept": nil}
+
+	var renames = findRenames(oldR, newR, failures)
+	if renames["moved"] != "gone" {
+		t.Errorf("Expected moved to be renamed from gone, got %q", renames["moved"])
+	}
+	if len(renames) != 1 {
+		t.Errorf("Expected content matching several new paths not to count as a rename, got %#v", renames)
+	}
+
+	newR.ChecksumAlgorithm = "md5"
+	renames = findRenames(oldR, newR, failures)
+	if len(renames) != 0 {
+		t.Errorf("Expected no renames across checksum algorithms, got %#v", renames)
+	}
+}
+
+func TestCompareFailures(t *testing.T) {
+	var oldList = []ReportFailure{
+		{Validator: "no-spaces", Code: "space"},
+		{Validator: "no-bad-chars", Code: "bad-char"},
+		{Validator: "has-extension", Code: "no-extension"},
+	}
+	var newList = []ReportFailure{
+		{Validator: "no-spaces", Code: "space"},
+		{Validator: "no-bad-chars", Code: "leading-dash"},
+	}
+	var newWaived = []ReportFailure{{Validator: "has-extension", Code: "no-extension"}}
+
+	var pd = &pathDiff{}
+	compareFailures(pd, oldList, newList, newWaived)
+	if len(pd.Unchanged) != 1 || pd.Unchanged[0].Code != "space" {
+		t.Errorf("Expected only the space finding to be unchanged, got %#v", pd.Unchanged)
+	}
+	if len(pd.Resolved) != 1 || pd.Resolved[0].Code != "bad-char" {
+		t.Errorf("Expected only the bad-char finding to be resolved, got %#v", pd.Resolved)
+	}
+	if len(pd.Waived) != 1 || pd.Waived[0].Code != "no-extension" {
+		t.Errorf("Expected only the no-extension finding to be waived, got %#v", pd.Waived)
+	}
+	if len(pd.New) != 1 || pd.New[0].Code != "leading-dash" {
+		t.Errorf("Expected only the leading-dash finding to be new, got %#v", pd.New)
+	}
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+)
+
+// DuplicateSet is a group of files which all have the same content
+type DuplicateSet struct {
+	Checksum string   `json:"checksum"`
+	Size     int64    `json:"size"`
+	Paths    []string `json:"paths"`
+
+	// Reclaimable is how many bytes would be freed by keeping just one copy
+	Reclaimable int64 `json:"reclaimable"`
+}
+
+// duplicateSets groups the report's checksummed files by content, returning
+// every group with more than one file.  The sets are sorted by reclaimable
+// bytes, largest first, and each set's paths are sorted.
+func duplicateSets(r *Report) []DuplicateSet {
+	var bySum = make(map[string][]string)
+	for path, sum := range r.Checksums {
+		bySum[sum] = append(bySum[sum], path)
+	}
+
+	var sets []DuplicateSet
+	for sum, paths := range bySum {
+		if len(paths) < 2 {
+			continue
+		}
+		sort.Strings(paths)
+		var ds = DuplicateSet{Checksum: sum, Paths: paths}
+		var info, err = os.Lstat(filepath.Join(rootPath, paths[0]))
+		if err == nil {
+			ds.Size = info.Size()
+			ds.Reclaimable = ds.Size * int64(len(paths)-1)
+		}
+		sets = append(sets, ds)
+	}
+
+	sort.Slice(sets, func(i, j int) bool {
+		if sets[i].Reclaimable != sets[j].Reclaimable {
+			return sets[i].Reclaimable > sets[j].Reclaimable
+		}
+		return sets[i].Paths[0] < sets[j].Paths[0]
+	})
+	return sets
+}
+
+// reclaimableBytes totals the bytes which could be freed across all sets
+func reclaimableBytes(sets []DuplicateSet) int64 {
+	var total int64
+	for _, ds := range sets {
+		total += ds.Reclaimable
+	}
+	return total
+}
+
+// writeDuplicatesReport writes a TSV with one row per duplicated file: each
+// set's rows share a set number, checksum, size, and reclaimable byte count
+func writeDuplicatesReport(fname string, r *Report) error {
+	var f, err = os.Create(fname)
+	if err != nil {
+		return err
+	}
+
+	writeProvenanceComments(f, r.Provenance)
+	fmt.Fprintf(f, "# %d duplicate sets, %d reclaimable bytes\n", len(r.Duplicates), reclaimableBytes(r.Duplicates))
+	err = printTSV(f, []string{"Set", "Checksum", "Size", "Copies", "Reclaimable", "Filename"})
+	for i, ds := range r.Duplicates {
+		for _, path := range ds.Paths {
+			if err != nil {
+				break
+			}
+			err = printTSV(f, []string{fmt.Sprint(i + 1), ds.Checksum, fmt.Sprint(ds.Size), fmt.Sprint(len(ds.Paths)),
+				fmt.Sprint(ds.Reclaimable), fmt.Sprintf("%#v", path)})
+		}
+	}
+
+	var closeErr = f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	return err
+}
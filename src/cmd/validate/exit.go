@@ -0,0 +1,48 @@
+package main
+
+import (
+	"github.com/uoregon-libraries/dark-archive-validator/src/rules"
+)
+
+// Exit codes: zero means nothing at or above the --fail-on threshold failed,
+// one is reserved for usage and runtime errors, and the rest tell scripts the
+// most severe criticality found
+const (
+	exitOK       = 0
+	exitError    = 1
+	exitLow      = 2
+	exitNormal   = 3
+	exitHigh     = 4
+	exitCritical = 5
+)
+
+// exitRegressed is returned by "validate diff" when the newer report has
+// findings the older one didn't
+const exitRegressed = 2
+
+// exitInvalid is returned by "validate verify-report" when a report fails
+// verification, and by "validate verify" when a tree doesn't match its
+// manifest
+const exitInvalid = 2
+
+var criticalityExitCodes = map[rules.Criticality]int{
+	rules.CLow:      exitLow,
+	rules.CNormal:   exitNormal,
+	rules.CHigh:     exitHigh,
+	rules.CCritical: exitCritical,
+}
+
+// exitCode returns the code for the most severe failure at or above the
+// threshold criticality, or exitOK if there were none
+func exitCode(threshold rules.Criticality, counts map[rules.Criticality]int) int {
+	for _, c := range []rules.Criticality{rules.CCritical, rules.CHigh, rules.CNormal, rules.CLow} {
+		if c > threshold {
+			break
+		}
+		if counts[c] > 0 {
+			return criticalityExitCodes[c]
+		}
+	}
+
+	return exitOK
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/uoregon-libraries/dark-archive-validator/src/rules"
+)
+
+// InventoryEntry describes one path the walk examined, whether or not it
+// passed validation.  Passed is only true for paths with no findings at all;
+// Status tells whether a path's findings were reported ("fail"), or all
+// waived ("waived") or suppressed by the baseline ("suppressed").
+type InventoryEntry struct {
+	Path     string    `json:"path"`
+	Type     string    `json:"type"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	Checksum string    `json:"checksum,omitempty"`
+	Passed   bool      `json:"passed"`
+	Status   string    `json:"status"`
+
+	reported bool
+}
+
+var inventory []InventoryEntry
+
+// inventoryWanted returns true if the run needs an inventory of every path.
+// PREMIS output needs one as well, since it has an event for every path, not
+// just failures.
+func inventoryWanted() bool {
+	return opts.Inventory != "" || opts.Format == "premis"
+}
+
+// recordInventory is an engine EntryFn which adds each examined path to the
+// inventory.  The engine only passes along reported failures, and checksums
+// aren't known until the whole run is done, so statuses and checksums are
+// filled in when the report is built.
+func recordInventory(path string, info os.FileInfo, fl []rules.Failure) {
+	var e = InventoryEntry{Path: path, Type: "unreadable", reported: len(fl) > 0}
+	if info != nil {
+		e.Type = fileType(info.Mode())
+		e.Size = info.Size()
+		e.Modified = info.ModTime()
+	}
+	inventory = append(inventory, e)
+}
+
+// fileType returns a short description of the kind of entry the mode
+// describes
+func fileType(m os.FileMode) string {
+	switch {
+	case m.IsRegular():
+		return "file"
+	case m.IsDir():
+		return "directory"
+	case m&os.ModeSymlink != 0:
+		return "symlink"
+	case m&os.ModeDevice != 0:
+		return "device"
+	case m&os.ModeNamedPipe != 0:
+		return "named-pipe"
+	case m&os.ModeSocket != 0:
+		return "socket"
+	default:
+		return "other"
+	}
+}
+
+// writeInventory writes the report's inventory as a TSV file
+func writeInventory(fname string, r *Report) error {
+	var f, err = os.Create(fname)
+	if err != nil {
+		return err
+	}
+
+	writeProvenanceComments(f, r.Provenance)
+	err = printTSV(f, []string{"Filename", "Type", "Size", "Modified", "Checksum", "Status"})
+	for _, e := range r.Inventory {
+		if err != nil {
+			break
+		}
+		var modified string
+		if !e.Modified.IsZero() {
+			modified = e.Modified.Format(time.RFC3339)
+		}
+		err = printTSV(f, []string{fmt.Sprintf("%#v", e.Path), e.Type, fmt.Sprint(e.Size), modified, e.Checksum, e.Status})
+	}
+
+	var closeErr = f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// buildInventory returns a copy of the inventory with checksums filled in
+// from the report, and each path's status based on its reported, waived, and
+// suppressed findings
+func buildInventory(r *Report) []InventoryEntry {
+	var waived = make(map[string]bool)
+	for _, w := range r.Waived {
+		waived[w.Path] = true
+	}
+	var suppressed = make(map[string]bool)
+	if acceptedFindings != nil {
+		for _, e := range acceptedFindings.suppressed() {
+			suppressed[e.Path] = true
+		}
+	}
+
+	var list = make([]InventoryEntry, len(inventory))
+	for i, e := range inventory {
+		e.Checksum = r.Checksums[e.Path]
+		switch {
+		case e.reported:
+			e.Status = "fail"
+		case waived[e.Path]:
+			e.Status = "waived"
+		case suppressed[e.Path]:
+			e.Status = "suppressed"
+		default:
+			e.Status = "pass"
+		}
+		e.Passed = e.Status == "pass"
+		list[i] = e
+	}
+	return list
+}
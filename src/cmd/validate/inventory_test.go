@@ -0,0 +1,33 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestBuildInventoryStatus(t *testing.T) {
+	var savedInventory, savedFindings = inventory, acceptedFindings
+	defer func() { inventory, acceptedFindings = savedInventory, savedFindings }()
+
+	inventory = []InventoryEntry{
+		{Path: "clean", Type: "file"},
+		{Path: "failed", Type: "file", reported: true},
+		{Path: "waived", Type: "file"},
+		{Path: "suppressed", Type: "file"},
+	}
+	acceptedFindings = newBaseline([]BaselineEntry{{Path: "suppressed", Validator: "no-spaces", Code: "space"}})
+	acceptedFindings.seen[BaselineEntry{Path: "suppressed", Validator: "no-spaces", Code: "space"}] = true
+
+	var r = &Report{
+		Checksums: map[string]string{"clean": "abc"},
+		Waived:    []ReportWaived{{ReportFailure: ReportFailure{Path: "waived", Validator: "no-spaces"}}},
+	}
+	var expected = map[string]string{"clean": "pass", "failed": "fail", "waived": "waived", "suppressed": "suppressed"}
+	for _, e := range buildInventory(r) {
+		if e.Status != expected[e.Path] {
+			t.Errorf("Expected %q to have status %q, got %q", e.Path, expected[e.Path], e.Status)
+		}
+		if e.Passed != (e.Path == "clean") {
+			t.Errorf("Expected only \"clean\" to have passed, but %q has Passed=%v", e.Path, e.Passed)
+		}
+	}
+}
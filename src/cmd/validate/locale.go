@@ -0,0 +1,42 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// localeDirs returns the directories searched for message catalogs when
+// --locale-dir isn't given: "locales" beside the executable, then beside its
+// parent directory (for bin/validate in a checkout), then in the working
+// directory
+func localeDirs() []string {
+	var dirs []string
+	var exe, err = os.Executable()
+	if err == nil {
+		exe, err = filepath.EvalSymlinks(exe)
+	}
+	if err == nil {
+		var exeDir = filepath.Dir(exe)
+		dirs = append(dirs, filepath.Join(exeDir, "locales"), filepath.Join(filepath.Dir(exeDir), "locales"))
+	}
+	return append(dirs, "locales")
+}
+
+// findCatalog returns the path to the catalog for lang.  If dir is empty, the
+// first of localeDirs holding the catalog is used.  When none has it, the
+// last candidate is returned so the error names a sensible path.
+func findCatalog(dir, lang string) string {
+	var name = lang + ".txt"
+	if dir != "" {
+		return filepath.Join(dir, name)
+	}
+
+	var candidate string
+	for _, d := range localeDirs() {
+		candidate = filepath.Join(d, name)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return candidate
+}
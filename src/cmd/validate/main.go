@@ -1,12 +1,16 @@
 package main
 
 import (
+	"bytes"
+	"crypto/ed25519"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"sort"
 	"strings"
 
+	"github.com/uoregon-libraries/dark-archive-validator/src/checksum"
 	"github.com/uoregon-libraries/dark-archive-validator/src/rules"
 )
 
@@ -18,27 +22,112 @@ type FileValidationFailure struct {
 
 var engine *rules.Engine
 var rootPath string
+var failOn rules.Criticality
+var acceptedFindings *baseline
+var reportTmpl reportTemplate
+var signingKey ed25519.PrivateKey
+var bagInfo []bagInfoField
+var bagCheck *rules.BagCheck
+var checksumCache *checksum.Cache
+
+// sumStore is shared by every validator which checksums files, so no file is
+// read twice for the same algorithm
+var sumStore *rules.SumStore
 var allValidatorNames []string
 var validatorNameIndices = make(map[string]int)
 var fileValidationFailures = make([]FileValidationFailure, 0)
 var checksums = make(map[string][]string)
+var digests = make(map[string]map[string]string)
+
+// sizeFirstChecksums is true when the size-first duplicate strategy is used,
+// which means most files never get a checksum
+var sizeFirstChecksums bool
+
+// subcommands maps the first argument to functions which take over the
+// command line for tasks other than validating a tree
+var subcommands = map[string]func(args []string) int{
+	"diff":          runDiff,
+	"verify":        runVerify,
+	"verify-report": runVerifyReport,
+}
 
 func main() {
+	if len(os.Args) > 1 {
+		if run, ok := subcommands[os.Args[1]]; ok {
+			os.Exit(run(os.Args[2:]))
+		}
+	}
+
 	engine = rules.NewEngine()
+	engine.Tool = toolName + " " + version
 	processCLI()
 	getAllValidators()
 	engine.ValidateTree(rootPath, failfunc)
-	exportValidationFailures()
+	if bagCheck != nil {
+		bagCheck.Finish(engine, failfunc)
+	}
+	var report = buildReport()
+	writeReport(report)
 
-	if opts.SHAOutput != "" {
-		writeSha()
+	if opts.ProfileRules {
+		printProfile(os.Stderr, report.Profile)
 	}
 
-	if len(fileValidationFailures) != 0 {
-		os.Exit(1)
+	if opts.Inventory != "" {
+		var err = writeInventory(opts.Inventory, report)
+		if err != nil {
+			log.Fatalf("Unable to write inventory: %s", err)
+		}
 	}
 
-	os.Exit(0)
+	if opts.DuplicatesReport != "" {
+		var err = writeDuplicatesReport(opts.DuplicatesReport, report)
+		if err != nil {
+			log.Fatalf("Unable to write duplicates report: %s", err)
+		}
+	}
+
+	if opts.WriteBaseline != "" {
+		var err = writeBaseline(opts.WriteBaseline, acceptedFindings)
+		if err != nil {
+			log.Fatalf("Unable to write baseline: %s", err)
+		}
+	}
+
+	if opts.ChecksumOutput != "" {
+		writeChecksums()
+	}
+
+	if opts.ManifestDir != "" {
+		var err = writeManifests(opts.ManifestDir, report)
+		if err != nil {
+			log.Fatalf("Unable to write manifests: %s", err)
+		}
+	}
+
+	var code = exitCode(failOn, engine.Stats.CriticalityFailures)
+	if opts.Bag != "" {
+		if code != exitOK {
+			log.Printf("Not creating bag: validation failed")
+		} else {
+			var err = writeBag(opts.Bag, report, bagInfo)
+			if err != nil {
+				log.Fatalf("Unable to create bag: %s", err)
+			}
+		}
+	}
+
+	// The cache is saved last, so failing to save it can't cost any of the
+	// outputs above
+	if checksumCache != nil {
+		checksumCache.Prune(rootPath)
+		var err = checksumCache.Save(opts.ChecksumCache)
+		if err != nil {
+			log.Fatalf("Unable to save checksum cache: %s", err)
+		}
+	}
+
+	os.Exit(code)
 }
 
 // getAllValidators puts together the complete list of validator names from a
@@ -57,14 +146,71 @@ func failfunc(path string, fList []rules.Failure) {
 	fileValidationFailures = append(fileValidationFailures, FileValidationFailure{path, fList})
 }
 
+// writeReport sends the run's results to stdout, or the requested report
+// file, in the requested format or through the user's template.  The TSV and
+// tree formats have no room for the run summary, so it's printed to stderr.
+func writeReport(r *Report) {
+	var out io.Writer = os.Stdout
+	var f *os.File
+	var err error
+	if opts.ReportFile != "" {
+		f, err = os.Create(opts.ReportFile)
+		if err != nil {
+			log.Fatalf("Unable to create report file: %s", err)
+		}
+		out = f
+	}
+
+	// Signed reports are rendered to memory first so the exact bytes written
+	// can be hashed
+	var buf *bytes.Buffer
+	if signingKey != nil {
+		buf = new(bytes.Buffer)
+		out = io.MultiWriter(out, buf)
+	}
+
+	switch {
+	case reportTmpl != nil:
+		err = writeTemplateReport(out, reportTmpl, r)
+	case opts.Format == "json":
+		err = writeJSONReport(out, r)
+	case opts.Format == "xlsx":
+		err = writeXLSXReport(out, r)
+	case opts.Format == "premis":
+		err = writePREMISReport(out, r)
+		printRunDetails(os.Stderr, r)
+	case opts.Format == "tree":
+		writeTreeReport(out, r, opts.TreeCollapse)
+		printRunDetails(os.Stderr, r)
+	default:
+		writeProvenanceComments(out, r.Provenance)
+		exportValidationFailures(out)
+		printRunDetails(os.Stderr, r)
+	}
+
+	if err == nil && f != nil {
+		err = f.Close()
+	}
+	if err != nil {
+		log.Fatalf("Unable to write report: %s", err)
+	}
+
+	if buf != nil {
+		err = signReport(buf.Bytes(), r, signingKey, opts.ReportFile+".sig")
+		if err != nil {
+			log.Fatalf("Unable to sign report: %s", err)
+		}
+	}
+}
+
 // exportValidationFailures prints out a CSV of failure data
-func exportValidationFailures() {
+func exportValidationFailures(w io.Writer) {
 	var header = make([]string, len(allValidatorNames)+1)
 	header[0] = "Filename"
 	for i, vName := range allValidatorNames {
 		header[i+1] = vName
 	}
-	printTSV(header)
+	printTSV(w, header)
 
 	for _, fvf := range fileValidationFailures {
 		// Prep the columns
@@ -76,31 +222,35 @@ func exportValidationFailures() {
 			columns[validatorNameIndices[f.V.Name]+1] = f.E.Error()
 		}
 
-		printTSV(columns)
+		printTSV(w, columns)
 	}
 }
 
-// printTSV just prints the strings in cols as-is, tab-separated
-func printTSV(cols []string) {
-	fmt.Println(strings.Join(cols, "\t"))
+// printTSV just prints the strings in cols as-is, tab-separated, returning any
+// write error
+func printTSV(w io.Writer, cols []string) error {
+	var _, err = fmt.Fprintln(w, strings.Join(cols, "\t"))
+	return err
 }
 
-func writeSha() {
+// writeChecksums writes every file's --algorithm checksum and full path to
+// the --checksum-output file
+func writeChecksums() {
 	var lines = make([]string, 0)
-	for sha, filenames := range checksums {
+	for sum, filenames := range checksums {
 		for _, filename := range filenames {
-			lines = append(lines, fmt.Sprintf("%s  %s", sha, filename))
+			lines = append(lines, fmt.Sprintf("%s  %s", sum, filename))
 		}
 	}
 	sort.Strings(lines)
 
-	var f, err = os.Create(opts.SHAOutput)
+	var f, err = os.Create(opts.ChecksumOutput)
 	if err == nil {
 		_, err = f.WriteString(strings.Join(lines, "\n"))
 	}
 
 	if err != nil {
-		log.Fatalf("Unable to write SHA checksums: %s", err)
+		log.Fatalf("Unable to write checksums: %s", err)
 	}
 
 	f.Close()
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/uoregon-libraries/dark-archive-validator/src/checksum"
+)
+
+// algorithmSums returns every computed digest, keyed by algorithm name and
+// then by path relative to the root
+func algorithmSums(r *Report) map[string]map[string]string {
+	var sums = make(map[string]map[string]string)
+	if len(r.Checksums) > 0 {
+		sums[r.ChecksumAlgorithm] = r.Checksums
+	}
+	for path, digests := range r.Digests {
+		for alg, sum := range digests {
+			if sums[alg] == nil {
+				sums[alg] = make(map[string]string)
+			}
+			sums[alg][path] = sum
+		}
+	}
+	return sums
+}
+
+// treeDigest returns the tree digest of the given sums, which are keyed by
+// relative path
+func treeDigest(alg string, sums map[string]string) string {
+	var slashed = make(map[string]string, len(sums))
+	for path, sum := range sums {
+		slashed[filepath.ToSlash(path)] = sum
+	}
+	// The algorithm is always one we computed sums with, so this can't fail
+	var d, _ = checksum.TreeDigest(alg, slashed)
+	return d
+}
+
+// writeManifests writes a sha256sum-style "manifest-<algorithm>.txt" file to
+// dir for each algorithm computed.  Paths are relative to the validated root,
+// so the manifests stay valid if the tree is moved.  When the run has a tree
+// digest, a "tree-digest.txt" file lists the tree digest for each algorithm,
+// keeping the manifests themselves in plain sha256sum format.
+func writeManifests(dir string, r *Report) error {
+	var sums = algorithmSums(r)
+	var algs []string
+	for alg := range sums {
+		algs = append(algs, alg)
+	}
+	sort.Strings(algs)
+
+	var trees []string
+	for _, alg := range algs {
+		var err = writeManifest(filepath.Join(dir, "manifest-"+alg+".txt"), sums[alg])
+		if err != nil {
+			return err
+		}
+		if r.TreeDigest != "" {
+			trees = append(trees, fmt.Sprintf("%s  %s\n", alg, treeDigest(alg, sums[alg])))
+		}
+	}
+
+	if len(trees) == 0 {
+		return nil
+	}
+	return ioutil.WriteFile(filepath.Join(dir, "tree-digest.txt"), []byte(strings.Join(trees, "")), 0666)
+}
+
+// writeManifest writes one "<digest>  <path>" line per file, sorted by path
+func writeManifest(fname string, sums map[string]string) error {
+	var paths []string
+	for path := range sums {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var f, err = os.Create(fname)
+	if err != nil {
+		return err
+	}
+	var w = bufio.NewWriter(f)
+	for _, path := range paths {
+		_, err = fmt.Fprintf(w, "%s  %s\n", sums[path], filepath.ToSlash(path))
+		if err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+	var closeErr = f.Close()
+	if err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// checkManifestDir creates dir if necessary and makes sure manifests can be
+// written to it, so a bad directory is caught before the tree is hashed
+func checkManifestDir(dir string) error {
+	var err = os.MkdirAll(dir, 0755)
+	if err != nil {
+		return err
+	}
+	var f *os.File
+	f, err = ioutil.TempFile(dir, ".manifest-check")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
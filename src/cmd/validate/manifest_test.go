@@ -0,0 +1,48 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteManifest(t *testing.T) {
+	var dir, err = ioutil.TempDir("", "manifest")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var manifestDir = filepath.Join(dir, "new", "manifests")
+	err = checkManifestDir(manifestDir)
+	if err != nil {
+		t.Fatalf("Expected the manifest dir to be created, got %s", err)
+	}
+
+	var fname = filepath.Join(manifestDir, "manifest-md5.txt")
+	err = writeManifest(fname, map[string]string{filepath.Join("b", "c"): "2222", "a": "1111"})
+	if err != nil {
+		t.Fatalf("Unable to write manifest: %s", err)
+	}
+	var data, _ = ioutil.ReadFile(fname)
+	var expected = "1111  a\n2222  b/c\n"
+	if string(data) != expected {
+		t.Errorf("Expected manifest %q, got %q", expected, data)
+	}
+
+	var entries, _ = ioutil.ReadDir(manifestDir)
+	if len(entries) != 1 {
+		t.Errorf("Expected checkManifestDir to leave nothing behind, got %d entries", len(entries))
+	}
+}
+
+func TestWriteManifestFullDisk(t *testing.T) {
+	if _, err := os.Stat("/dev/full"); err != nil {
+		t.Skip("/dev/full isn't available")
+	}
+	var err = writeManifest("/dev/full", map[string]string{"a": "1111"})
+	if err == nil {
+		t.Errorf("Expected an error writing to a full disk")
+	}
+}
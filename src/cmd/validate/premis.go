@@ -0,0 +1,244 @@
+package main
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+const premisNamespace = "http://www.loc.gov/premis/v3"
+
+// premisDoc is the root of a PREMIS 3 document.  Only the handful of elements
+// we can fill in are modeled.
+type premisDoc struct {
+	XMLName xml.Name       `xml:"premis"`
+	XMLNS   string         `xml:"xmlns,attr"`
+	XSI     string         `xml:"xmlns:xsi,attr"`
+	Version string         `xml:"version,attr"`
+	Objects []premisObject `xml:"object"`
+	Events  []premisEvent  `xml:"event"`
+	Agents  []premisAgent  `xml:"agent"`
+}
+
+// premisObject describes one path.  Regular files are file objects with
+// their size and fixity; anything else, such as a directory, is a
+// representation object, which PREMIS doesn't require characteristics for.
+type premisObject struct {
+	XSIType         string                `xml:"xsi:type,attr"`
+	Identifier      premisObjectID        `xml:"objectIdentifier"`
+	Characteristics *premisCharacteristic `xml:"objectCharacteristics,omitempty"`
+	OriginalName    string                `xml:"originalName"`
+}
+
+type premisObjectID struct {
+	Type  string `xml:"objectIdentifierType"`
+	Value string `xml:"objectIdentifierValue"`
+}
+
+type premisCharacteristic struct {
+	CompositionLevel int            `xml:"compositionLevel"`
+	Fixity           []premisFixity `xml:"fixity"`
+	Size             int64          `xml:"size"`
+	FormatName       string         `xml:"format>formatDesignation>formatName"`
+}
+
+type premisFixity struct {
+	Algorithm  string `xml:"messageDigestAlgorithm"`
+	Digest     string `xml:"messageDigest"`
+	Originator string `xml:"messageDigestOriginator"`
+}
+
+type premisEvent struct {
+	Identifier   premisEventID        `xml:"eventIdentifier"`
+	Type         string               `xml:"eventType"`
+	DateTime     string               `xml:"eventDateTime"`
+	Detail       string               `xml:"eventDetailInformation>eventDetail"`
+	Outcome      premisOutcome        `xml:"eventOutcomeInformation"`
+	LinkingAgent premisLinkingAgent   `xml:"linkingAgentIdentifier"`
+	LinkingObj   *premisLinkingObject `xml:"linkingObjectIdentifier"`
+}
+
+type premisEventID struct {
+	Type  string `xml:"eventIdentifierType"`
+	Value string `xml:"eventIdentifierValue"`
+}
+
+type premisOutcome struct {
+	Outcome string                `xml:"eventOutcome"`
+	Details []premisOutcomeDetail `xml:"eventOutcomeDetail"`
+}
+
+type premisOutcomeDetail struct {
+	Note string `xml:"eventOutcomeDetailNote"`
+}
+
+type premisLinkingAgent struct {
+	Type  string `xml:"linkingAgentIdentifierType"`
+	Value string `xml:"linkingAgentIdentifierValue"`
+	Role  string `xml:"linkingAgentRole"`
+}
+
+type premisLinkingObject struct {
+	Type  string `xml:"linkingObjectIdentifierType"`
+	Value string `xml:"linkingObjectIdentifierValue"`
+}
+
+type premisAgent struct {
+	Identifier premisAgentID `xml:"agentIdentifier"`
+	Name       string        `xml:"agentName"`
+	Type       string        `xml:"agentType"`
+	Version    string        `xml:"agentVersion"`
+	Notes      []string      `xml:"agentNote"`
+}
+
+type premisAgentID struct {
+	Type  string `xml:"agentIdentifierType"`
+	Value string `xml:"agentIdentifierValue"`
+}
+
+// premisIDType is the identifier type used for every object, event, and
+// agent: objects are identified by their path relative to the validated root
+const premisIDType = "local"
+
+// premisRunID identifies a run by its start time, so events from different
+// runs on the same tree don't share identifiers when stored together
+func premisRunID(r *Report) string {
+	return r.Summary.Start.UTC().Format("20060102T150405.000000000Z")
+}
+
+// writePREMISReport writes the report as a PREMIS 3 document: one object per
+// path examined (a file object for each regular file, and a representation
+// object for anything else), a validation event for every path, and a
+// message digest calculation event for every checksummed file.  Every event
+// links to its path's object and to a single software agent whose notes hold
+// the run's provenance.  PREMIS requires at least one object, so an empty
+// tree can't be described, and is an error.
+func writePREMISReport(w io.Writer, r *Report) error {
+	if len(r.Inventory) == 0 {
+		return fmt.Errorf("PREMIS requires at least one object, but no paths were examined")
+	}
+
+	var doc = premisDoc{XMLNS: premisNamespace, XSI: "http://www.w3.org/2001/XMLSchema-instance", Version: "3.0"}
+	var agentID = toolName + "/" + version
+	doc.Agents = []premisAgent{{
+		Identifier: premisAgentID{premisIDType, agentID},
+		Name:       toolName,
+		Type:       "software",
+		Version:    version,
+		Notes:      provenanceLines(r.Provenance),
+	}}
+
+	var notes = premisNotes(r)
+	var sums = algorithmSums(r)
+	var algs []string
+	for alg := range sums {
+		algs = append(algs, alg)
+	}
+	sort.Strings(algs)
+	var when = r.Summary.End.Format(time.RFC3339)
+	var runID = premisRunID(r)
+	var newEvent = func(kind, path, detail, outcome string) premisEvent {
+		return premisEvent{
+			Identifier:   premisEventID{premisIDType, kind + "/" + runID + "/" + path},
+			Type:         kind,
+			DateTime:     when,
+			Detail:       detail,
+			Outcome:      premisOutcome{Outcome: outcome, Details: notes[path]},
+			LinkingAgent: premisLinkingAgent{premisIDType, agentID, "executing program"},
+			LinkingObj:   &premisLinkingObject{premisIDType, path},
+		}
+	}
+
+	const validationDetail = "Dark archive file and path validation"
+	for _, e := range r.Inventory {
+		var outcome = "pass"
+		if !e.Passed {
+			outcome = "fail"
+		}
+
+		var obj = premisObject{
+			XSIType:      "file",
+			Identifier:   premisObjectID{premisIDType, e.Path},
+			OriginalName: e.Path,
+		}
+		if e.Type != "file" {
+			obj.XSIType = "representation"
+			doc.Objects = append(doc.Objects, obj)
+			doc.Events = append(doc.Events, newEvent("validation", e.Path, fmt.Sprintf("%s of %s", validationDetail, e.Type), outcome))
+			continue
+		}
+		doc.Events = append(doc.Events, newEvent("validation", e.Path, validationDetail, outcome))
+
+		obj.Characteristics = &premisCharacteristic{Size: e.Size, FormatName: "unknown"}
+		var names []string
+		for _, alg := range algs {
+			var sum = sums[alg][e.Path]
+			if sum == "" {
+				continue
+			}
+			var name = premisAlgorithmName(alg)
+			names = append(names, name)
+			obj.Characteristics.Fixity = append(obj.Characteristics.Fixity, premisFixity{name, sum, toolName})
+		}
+		if len(names) > 0 {
+			var ev = newEvent("message digest calculation", e.Path, strings.Join(names, ", ")+" calculated", "success")
+			ev.Outcome.Details = nil
+			doc.Events = append(doc.Events, ev)
+		}
+		doc.Objects = append(doc.Objects, obj)
+	}
+
+	var _, err = io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+	var enc = xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	err = enc.Encode(doc)
+	if err == nil {
+		_, err = io.WriteString(w, "\n")
+	}
+	return err
+}
+
+// premisAlgorithmNames maps our algorithm names to the names commonly used in
+// PREMIS messageDigestAlgorithm values
+var premisAlgorithmNames = map[string]string{
+	"md5":     "MD5",
+	"sha1":    "SHA-1",
+	"sha256":  "SHA-256",
+	"sha512":  "SHA-512",
+	"blake2b": "BLAKE2b-512",
+}
+
+func premisAlgorithmName(alg string) string {
+	var name = premisAlgorithmNames[alg]
+	if name == "" {
+		return alg
+	}
+	return name
+}
+
+// premisNotes gathers each path's failure and waiver messages for use as
+// validation event outcome details
+func premisNotes(r *Report) map[string][]premisOutcomeDetail {
+	var notes = make(map[string][]string)
+	for _, f := range r.Failures {
+		notes[f.Path] = append(notes[f.Path], f.Validator+": "+f.Message)
+	}
+	for _, wf := range r.Waived {
+		notes[wf.Path] = append(notes[wf.Path], "waived by "+wf.ApprovedBy+": "+wf.Validator+": "+wf.Message)
+	}
+
+	var details = make(map[string][]premisOutcomeDetail)
+	for path, list := range notes {
+		sort.Strings(list)
+		for _, note := range list {
+			details[path] = append(details[path], premisOutcomeDetail{note})
+		}
+	}
+	return details
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/uoregon-libraries/dark-archive-validator/src/rules"
+)
+
+// premisTestDoc pulls just the identifiers out of a PREMIS document
+type premisTestDoc struct {
+	Objects []string `xml:"object>objectIdentifier>objectIdentifierValue"`
+	Events  []struct {
+		ID     string   `xml:"eventIdentifier>eventIdentifierValue"`
+		Type   string   `xml:"eventType"`
+		Detail string   `xml:"eventDetailInformation>eventDetail"`
+		Linked []string `xml:"linkingObjectIdentifier>linkingObjectIdentifierValue"`
+		Notes  []string `xml:"eventOutcomeInformation>eventOutcomeDetail>eventOutcomeDetailNote"`
+	} `xml:"event"`
+}
+
+func premisTestReport(start time.Time) *Report {
+	return &Report{
+		Provenance:        &rules.Provenance{Tool: "test"},
+		Summary:           Summary{Start: start, End: start.Add(time.Minute)},
+		Checksums:         map[string]string{"dir/a.txt": "abc123"},
+		ChecksumAlgorithm: "sha256",
+		Failures:          []ReportFailure{{Path: "dir/a.txt", Validator: "no-spaces", Message: "has a space"}},
+		Inventory: []InventoryEntry{
+			{Path: "dir", Type: "directory", Passed: true},
+			{Path: "dir/a.txt", Type: "file", Size: 10, Passed: false},
+		},
+	}
+}
+
+func parsePREMIS(t *testing.T, r *Report) premisTestDoc {
+	var buf bytes.Buffer
+	var err = writePREMISReport(&buf, r)
+	if err != nil {
+		t.Fatalf("Unable to write PREMIS: %s", err)
+	}
+	var doc premisTestDoc
+	err = xml.Unmarshal(buf.Bytes(), &doc)
+	if err != nil {
+		t.Fatalf("Unable to parse PREMIS: %s", err)
+	}
+	return doc
+}
+
+func TestPREMISReport(t *testing.T) {
+	var doc = parsePREMIS(t, premisTestReport(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)))
+
+	if len(doc.Objects) != 2 || doc.Objects[0] != "dir" || doc.Objects[1] != "dir/a.txt" {
+		t.Errorf("Expected objects for dir and dir/a.txt, got %#v", doc.Objects)
+	}
+
+	var objects = make(map[string]bool)
+	for _, o := range doc.Objects {
+		objects[o] = true
+	}
+	var ids = make(map[string]bool)
+	for _, ev := range doc.Events {
+		if ids[ev.ID] {
+			t.Errorf("Duplicate event identifier %q", ev.ID)
+		}
+		ids[ev.ID] = true
+		if len(ev.Linked) == 0 {
+			t.Errorf("Event %q isn't linked to an object", ev.ID)
+		}
+		for _, linked := range ev.Linked {
+			if !objects[linked] {
+				t.Errorf("Event %q links to undescribed object %q", ev.ID, linked)
+			}
+		}
+	}
+	if len(doc.Events) != 3 {
+		t.Fatalf("Expected 3 events, got %d", len(doc.Events))
+	}
+
+	var dirEvent = doc.Events[0]
+	if len(dirEvent.Linked) != 1 || dirEvent.Linked[0] != "dir" || !strings.HasSuffix(dirEvent.Detail, "of directory") {
+		t.Errorf("Expected the directory event to link to the directory's object, got %#v", dirEvent)
+	}
+	var fileEvent = doc.Events[1]
+	if len(fileEvent.Notes) != 1 || fileEvent.Notes[0] != "no-spaces: has a space" {
+		t.Errorf("Expected the file's failure as an outcome note, got %#v", fileEvent.Notes)
+	}
+	if doc.Events[2].Type != "message digest calculation" {
+		t.Errorf("Expected a message digest event, got %q", doc.Events[2].Type)
+	}
+}
+
+func TestPREMISEventIDsPerRun(t *testing.T) {
+	var start = time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	var first = parsePREMIS(t, premisTestReport(start))
+	var second = parsePREMIS(t, premisTestReport(start.Add(time.Hour)))
+	for i := range first.Events {
+		if first.Events[i].ID == second.Events[i].ID {
+			t.Errorf("Expected event IDs to differ between runs, both were %q", first.Events[i].ID)
+		}
+	}
+}
+
+func TestPREMISEmptyTree(t *testing.T) {
+	var r = premisTestReport(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+	r.Inventory = nil
+	var buf bytes.Buffer
+	var err = writePREMISReport(&buf, r)
+	if err == nil {
+		t.Errorf("Expected an error describing an empty tree")
+	}
+}
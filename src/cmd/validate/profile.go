@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/uoregon-libraries/dark-archive-validator/src/rules"
+)
+
+// ReportTiming is the cumulative cost of one validator over a run
+type ReportTiming struct {
+	Validator    string  `json:"validator"`
+	Calls        int     `json:"calls"`
+	TotalSeconds float64 `json:"total_seconds"`
+}
+
+// buildProfile returns the validators' timings, most expensive first
+func buildProfile(s *rules.Stats) []ReportTiming {
+	var list []ReportTiming
+	for name, t := range s.Timings {
+		list = append(list, ReportTiming{Validator: name, Calls: t.Calls, TotalSeconds: t.Duration.Seconds()})
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].TotalSeconds != list[j].TotalSeconds {
+			return list[i].TotalSeconds > list[j].TotalSeconds
+		}
+		return list[i].Validator < list[j].Validator
+	})
+	return list
+}
+
+// printProfile writes a table of validator timings, including each
+// validator's share of the total time spent validating
+func printProfile(w io.Writer, list []ReportTiming) {
+	var total float64
+	for _, t := range list {
+		total += t.TotalSeconds
+	}
+
+	fmt.Fprintf(w, "%-24s %10s %12s %12s %7s\n", "Validator", "Calls", "Total", "Average", "Share")
+	for _, t := range list {
+		var avg, share float64
+		if t.Calls > 0 {
+			avg = t.TotalSeconds / float64(t.Calls)
+		}
+		if total > 0 {
+			share = t.TotalSeconds / total * 100
+		}
+		fmt.Fprintf(w, "%-24s %10d %12s %12s %6.1f%%\n", t.Validator, t.Calls,
+			seconds(t.TotalSeconds), seconds(avg), share)
+	}
+}
+
+// seconds formats a number of seconds as a rounded duration
+func seconds(s float64) string {
+	var d = time.Duration(s * float64(time.Second))
+	switch {
+	case d >= time.Second:
+		d = d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		d = d.Round(time.Microsecond)
+	}
+	return d.String()
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/uoregon-libraries/dark-archive-validator/src/rules"
+)
+
+// provenanceLines returns the run's provenance as "label: value" lines,
+// suitable for comments or notes in formats without a structured place
+// for it
+func provenanceLines(p *rules.Provenance) []string {
+	if p == nil {
+		return nil
+	}
+
+	var lines = []string{
+		"tool: " + p.Tool,
+		"host: " + p.Host,
+		"user: " + p.User,
+		"root: " + p.Root,
+		"time: " + p.Time.Format(time.RFC3339),
+		"rule set hash: " + p.RuleSetHash,
+	}
+	for _, vi := range p.Validators {
+		lines = append(lines, "validator: "+validatorDescription(vi))
+	}
+	return lines
+}
+
+// validatorDescription returns a one-line description of a validator and its
+// settings
+func validatorDescription(vi rules.ValidatorInfo) string {
+	var desc = fmt.Sprintf("%s (%s)", vi.Name, vi.Criticality)
+	var keys []string
+	for k := range vi.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var params []string
+	for _, k := range keys {
+		params = append(params, fmt.Sprintf("%s=%v", k, vi.Params[k]))
+	}
+	if len(params) > 0 {
+		desc += " " + strings.Join(params, " ")
+	}
+	return desc
+}
+
+// writeProvenanceComments writes the run's provenance as "#" comment lines
+// at the top of a TSV file
+func writeProvenanceComments(w io.Writer, p *rules.Provenance) {
+	for _, line := range provenanceLines(p) {
+		fmt.Fprintf(w, "# %s\n", line)
+	}
+}
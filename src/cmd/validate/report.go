@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"time"
+
+	"github.com/uoregon-libraries/dark-archive-validator/src/rules"
+)
+
+// Report is the structured form of a validation run, used by the output
+// formats other than the default TSV
+type Report struct {
+	Validators []ReportValidator `json:"validators"`
+	Failures   []ReportFailure   `json:"failures"`
+	Summary    Summary           `json:"summary"`
+	Waived     []ReportWaived    `json:"waived"`
+	Baseline   *BaselineReport   `json:"baseline,omitempty"`
+
+	// Provenance describes the run: tool, host, user, root, time, and the
+	// exact rule set applied
+	Provenance *rules.Provenance `json:"provenance"`
+
+	// Checksums maps each checksummed file's path to its hex digest, allowing
+	// renamed files to be recognized when comparing reports
+	Checksums         map[string]string `json:"checksums,omitempty"`
+	ChecksumAlgorithm string            `json:"checksum_algorithm,omitempty"`
+
+	// TreeDigest is a single digest of every file's content and path, using
+	// ChecksumAlgorithm; it's left out unless every regular file was
+	// checksummed
+	TreeDigest string `json:"tree_digest,omitempty"`
+
+	// Digests maps each file's path to any --extra-hash digests, keyed by
+	// algorithm name
+	Digests map[string]map[string]string `json:"digests,omitempty"`
+
+	// Duplicates lists every set of files sharing the same content, largest
+	// waste first
+	Duplicates []DuplicateSet `json:"duplicates,omitempty"`
+
+	// Inventory lists every path examined, and is only filled in when an
+	// inventory or PREMIS output was requested
+	Inventory []InventoryEntry `json:"inventory,omitempty"`
+
+	// Profile holds per-validator timings when --profile-rules is used
+	Profile []ReportTiming `json:"profile,omitempty"`
+}
+
+// ReportValidator describes one validator which was run
+type ReportValidator struct {
+	Name        string            `json:"name"`
+	Criticality rules.Criticality `json:"criticality"`
+}
+
+// ReportFailure is a single finding: one validator's complaint about one path
+type ReportFailure struct {
+	Path        string            `json:"path"`
+	Validator   string            `json:"validator"`
+	Criticality rules.Criticality `json:"criticality"`
+	Code        string            `json:"code"`
+	Message     string            `json:"message"`
+}
+
+// ReportWaived is a finding which was accepted by a waiver, along with the
+// waiver's details
+type ReportWaived struct {
+	ReportFailure
+	ApprovedBy    string `json:"approved_by"`
+	Justification string `json:"justification"`
+	Expires       string `json:"expires,omitempty"`
+}
+
+// BaselineReport tells how the run compared to a baseline: failures are only
+// reported if they're new, so this holds what was suppressed and what's fixed
+type BaselineReport struct {
+	File       string          `json:"file"`
+	Suppressed int             `json:"suppressed"`
+	Fixed      []BaselineEntry `json:"fixed"`
+}
+
+// Summary holds the run statistics gathered by the engine
+type Summary struct {
+	Files               int                       `json:"files"`
+	Directories         int                       `json:"directories"`
+	Bytes               int64                     `json:"bytes"`
+	FailedPaths         int                       `json:"failed_paths"`
+	Waived              int                       `json:"waived"`
+	Failures            int                       `json:"failures"`
+	ValidatorFailures   map[string]int            `json:"validator_failures"`
+	CriticalityFailures map[rules.Criticality]int `json:"criticality_failures"`
+	Start               time.Time                 `json:"start"`
+	End                 time.Time                 `json:"end"`
+	DurationSeconds     float64                   `json:"duration_seconds"`
+
+	// ReclaimableBytes totals the space taken by extra copies of duplicated
+	// files
+	ReclaimableBytes int64 `json:"reclaimable_bytes"`
+
+	// ChecksumCache is only set when --checksum-cache is used
+	ChecksumCache *CacheSummary `json:"checksum_cache,omitempty"`
+}
+
+// CacheSummary tells how much the checksum cache helped
+type CacheSummary struct {
+	File   string `json:"file"`
+	Hits   int    `json:"hits"`
+	Misses int    `json:"misses"`
+}
+
+// buildReport gathers the validators, failures, and engine stats from the
+// most recent run
+func buildReport() *Report {
+	var r = &Report{Failures: make([]ReportFailure, 0), Waived: make([]ReportWaived, 0), Provenance: engine.Provenance}
+	for _, v := range engine.Validators() {
+		r.Validators = append(r.Validators, ReportValidator{v.Name, v.Criticality})
+	}
+
+	for _, fvf := range fileValidationFailures {
+		for _, f := range fvf.Failures {
+			r.Failures = append(r.Failures, newReportFailure(fvf.Filepath, f))
+		}
+	}
+
+	for _, w := range engine.Waived {
+		r.Waived = append(r.Waived, ReportWaived{
+			ReportFailure: newReportFailure(w.Path, w.Failure),
+			ApprovedBy:    w.Waiver.ApprovedBy,
+			Justification: w.Waiver.Justification,
+			Expires:       w.Waiver.Expires,
+		})
+	}
+
+	for sum, fullPaths := range checksums {
+		for _, fullPath := range fullPaths {
+			if r.Checksums == nil {
+				r.Checksums = make(map[string]string)
+			}
+			r.Checksums[relativePath(fullPath)] = sum
+		}
+	}
+	if r.Checksums != nil {
+		r.ChecksumAlgorithm = opts.Algorithm
+	}
+	if checksumsComplete(r) {
+		r.TreeDigest = treeDigest(opts.Algorithm, r.Checksums)
+	}
+
+	for fullPath, d := range digests {
+		if r.Digests == nil {
+			r.Digests = make(map[string]map[string]string)
+		}
+		r.Digests[relativePath(fullPath)] = d
+	}
+
+	r.Duplicates = duplicateSets(r)
+
+	if inventoryWanted() {
+		r.Inventory = buildInventory(r)
+	}
+
+	if opts.ProfileRules {
+		r.Profile = buildProfile(engine.Stats)
+	}
+
+	var s = engine.Stats
+	r.Summary = Summary{
+		Files:               s.Files,
+		Directories:         s.Directories,
+		Bytes:               s.Bytes,
+		FailedPaths:         s.FailedPaths,
+		Waived:              s.Waived,
+		Failures:            s.Failures(),
+		ValidatorFailures:   s.ValidatorFailures,
+		CriticalityFailures: s.CriticalityFailures,
+		Start:               s.Start,
+		End:                 s.End,
+		DurationSeconds:     s.Duration().Seconds(),
+		ReclaimableBytes:    reclaimableBytes(r.Duplicates),
+	}
+	if checksumCache != nil {
+		r.Summary.ChecksumCache = &CacheSummary{opts.ChecksumCache, checksumCache.Hits, checksumCache.Misses}
+	}
+
+	if acceptedFindings != nil {
+		r.Baseline = &BaselineReport{
+			File:       opts.Baseline,
+			Suppressed: len(acceptedFindings.seen),
+			Fixed:      acceptedFindings.fixed(engine.Waived),
+		}
+	}
+
+	return r
+}
+
+// checksumsComplete returns true if every regular file the run examined was
+// checksummed.  That isn't the case when no-duped-content is skipped or uses
+// the size-first strategy, or when any file couldn't be checksummed, either
+// due to a read error or because an earlier failure stopped validation.
+func checksumsComplete(r *Report) bool {
+	if sizeFirstChecksums {
+		return false
+	}
+	var ran bool
+	for _, v := range engine.Validators() {
+		if v.Name == "no-duped-content" {
+			ran = true
+		}
+	}
+	return ran && len(r.Checksums) == engine.Stats.RegularFiles
+}
+
+// relativePath strips the root path from a full path, for reporting paths
+// the same way the engine does
+func relativePath(fullPath string) string {
+	var rel, err = filepath.Rel(rootPath, fullPath)
+	if err != nil {
+		return fullPath
+	}
+	return rel
+}
+
+func newReportFailure(path string, f rules.Failure) ReportFailure {
+	return ReportFailure{
+		Path:        path,
+		Validator:   f.V.Name,
+		Criticality: f.V.Criticality,
+		Code:        f.Code(),
+		Message:     f.E.Error(),
+	}
+}
+
+// writeJSONReport writes the report as indented JSON
+func writeJSONReport(w io.Writer, r *Report) error {
+	var enc = json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"time"
+
+	"github.com/jessevdk/go-flags"
+)
+
+// ReportSignature is written alongside a signed report.  Signed holds the
+// JSON-encoded SignedReportInfo; its compact form is what gets signed.
+type ReportSignature struct {
+	Signed    json.RawMessage `json:"signed"`
+	PublicKey string          `json:"public_key"`
+	Signature string          `json:"signature"`
+}
+
+// SignedReportInfo ties the signature to a specific report and run, so a
+// valid signature can't be moved to a different report
+type SignedReportInfo struct {
+	Algorithm    string    `json:"algorithm"`
+	ReportFormat string    `json:"report_format"`
+	ReportSHA256 string    `json:"report_sha256"`
+	SignedAt     time.Time `json:"signed_at"`
+	Run          RunInfo   `json:"run"`
+}
+
+// RunInfo is the run metadata embedded in a signature, checked against the
+// report itself when the report is JSON
+type RunInfo struct {
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Files       int       `json:"files"`
+	Directories int       `json:"directories"`
+	Failures    int       `json:"failures"`
+}
+
+func newRunInfo(s Summary) RunInfo {
+	return RunInfo{Start: s.Start, End: s.End, Files: s.Files, Directories: s.Directories, Failures: s.Failures}
+}
+
+// readPrivateKey loads a PEM-encoded PKCS #8 ed25519 private key, such as
+// "openssl genpkey -algorithm ed25519" generates
+func readPrivateKey(fname string) (ed25519.PrivateKey, error) {
+	var der, err = readPEM(fname, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	var key interface{}
+	key, err = x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, err
+	}
+	var edKey, ok = key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an ed25519 private key", fname)
+	}
+	return edKey, nil
+}
+
+// readPublicKey loads a PEM-encoded PKIX ed25519 public key, such as
+// "openssl pkey -pubout" generates
+func readPublicKey(fname string) (ed25519.PublicKey, error) {
+	var der, err = readPEM(fname, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+
+	var key interface{}
+	key, err = x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, err
+	}
+	var edKey, ok = key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("%s is not an ed25519 public key", fname)
+	}
+	return edKey, nil
+}
+
+func readPEM(fname, blockType string) ([]byte, error) {
+	var data, err = ioutil.ReadFile(fname)
+	if err != nil {
+		return nil, err
+	}
+	var block, _ = pem.Decode(data)
+	if block == nil || block.Type != blockType {
+		return nil, fmt.Errorf("%s does not contain a PEM %q block", fname, blockType)
+	}
+	return block.Bytes, nil
+}
+
+// keyFingerprint returns a short identifier for a public key
+func keyFingerprint(pub ed25519.PublicKey) string {
+	var sum = sha256.Sum256(pub)
+	return hex.EncodeToString(sum[:8])
+}
+
+// signReport signs the rendered report and writes the signature file
+func signReport(data []byte, r *Report, key ed25519.PrivateKey, sigFile string) error {
+	var sum = sha256.Sum256(data)
+	var info = SignedReportInfo{
+		Algorithm:    "ed25519",
+		ReportFormat: reportFormat(),
+		ReportSHA256: hex.EncodeToString(sum[:]),
+		SignedAt:     time.Now(),
+		Run:          newRunInfo(r.Summary),
+	}
+
+	var signed, err = json.Marshal(info)
+	if err != nil {
+		return err
+	}
+
+	var sig = ReportSignature{
+		Signed:    signed,
+		PublicKey: base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey)),
+		Signature: base64.StdEncoding.EncodeToString(ed25519.Sign(key, signed)),
+	}
+
+	var out []byte
+	out, err = json.MarshalIndent(sig, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(sigFile, append(out, '\n'), 0666)
+}
+
+// reportFormat returns the name of the format the report was written in
+func reportFormat() string {
+	if opts.Template != "" {
+		return "template"
+	}
+	return opts.Format
+}
+
+var verifyReportOpts struct {
+	Signature string `long:"signature" description:"Signature file to check (default: the report filename plus .sig)"`
+	PublicKey string `long:"public-key" required:"true" description:"PEM public key the report must be signed with; the key embedded in the signature file is only trusted if it matches"`
+}
+
+// verifyReport checks a report against its signature and the trusted public
+// key, returning a list of problems found.  The signature's key is returned so
+// it can be reported.
+func verifyReport(data []byte, sig *ReportSignature, trusted ed25519.PublicKey) (ed25519.PublicKey, *SignedReportInfo, []string) {
+	var problems []string
+	var pub, err = base64.StdEncoding.DecodeString(sig.PublicKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return nil, nil, []string{"signature file has an invalid public key"}
+	}
+	var sigBytes []byte
+	sigBytes, err = base64.StdEncoding.DecodeString(sig.Signature)
+	if err != nil {
+		return nil, nil, []string{"signature file has an invalid signature"}
+	}
+
+	var signed bytes.Buffer
+	err = json.Compact(&signed, sig.Signed)
+	if err != nil {
+		return nil, nil, []string{"signature file has invalid signed data"}
+	}
+
+	if !bytes.Equal(trusted, pub) {
+		problems = append(problems, "report was not signed with the trusted public key")
+	}
+	if !ed25519.Verify(pub, signed.Bytes(), sigBytes) {
+		return pub, nil, append(problems, "signature does not match the signed data")
+	}
+
+	var info SignedReportInfo
+	err = json.Unmarshal(signed.Bytes(), &info)
+	if err != nil {
+		return pub, nil, append(problems, fmt.Sprintf("signed data is invalid: %s", err))
+	}
+
+	var sum = sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != info.ReportSHA256 {
+		problems = append(problems, "report has been modified since it was signed")
+	}
+
+	if info.ReportFormat == "json" {
+		var r Report
+		err = json.Unmarshal(data, &r)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("report is not valid JSON: %s", err))
+		} else {
+			problems = append(problems, checkRunInfo(info.Run, newRunInfo(r.Summary))...)
+		}
+	}
+	if info.SignedAt.Before(info.Run.End) {
+		problems = append(problems, "report was signed before the run finished")
+	}
+
+	return pub, &info, problems
+}
+
+// checkRunInfo compares the signed run metadata to the report's
+func checkRunInfo(signed, actual RunInfo) []string {
+	var problems []string
+	if !signed.Start.Equal(actual.Start) || !signed.End.Equal(actual.End) {
+		problems = append(problems, "report's run times don't match the signature")
+	}
+	if signed.Files != actual.Files || signed.Directories != actual.Directories {
+		problems = append(problems, "report's file counts don't match the signature")
+	}
+	if signed.Failures != actual.Failures {
+		problems = append(problems, "report's failure count doesn't match the signature")
+	}
+	return problems
+}
+
+// runVerifyReport implements "validate verify-report"
+func runVerifyReport(args []string) int {
+	var p = flags.NewParser(&verifyReportOpts, flags.HelpFlag)
+	p.Usage = "verify-report [OPTIONS] <report>"
+	p.LongDescription = "Checks a report written with --sign-key against its signature, " +
+		"the run metadata embedded in the signature, and the trusted --public-key.  " +
+		"Exits 0 if the report is valid, 1 on usage or runtime errors, and 2 if " +
+		"verification failed."
+
+	var more, err = p.ParseArgs(args)
+	if err == nil && len(more) != 1 {
+		err = fmt.Errorf("must specify exactly one report to verify")
+	}
+	if err != nil {
+		subcommandUsage(p, err)
+	}
+
+	var reportFile = more[0]
+	var sigFile = verifyReportOpts.Signature
+	if sigFile == "" {
+		sigFile = reportFile + ".sig"
+	}
+
+	var trusted ed25519.PublicKey
+	trusted, err = readPublicKey(verifyReportOpts.PublicKey)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: unable to read public key: %s\n", err)
+		return exitError
+	}
+
+	var data, sigData []byte
+	data, err = ioutil.ReadFile(reportFile)
+	if err == nil {
+		sigData, err = ioutil.ReadFile(sigFile)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		return exitError
+	}
+
+	var sig ReportSignature
+	err = json.Unmarshal(sigData, &sig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s is not a valid signature file: %s\n", sigFile, err)
+		return exitError
+	}
+
+	var pub, info, problems = verifyReport(data, &sig, trusted)
+	if pub != nil {
+		fmt.Printf("Signing key:  %s\n", keyFingerprint(pub))
+	}
+	if info != nil {
+		fmt.Printf("Signed at:    %s\n", info.SignedAt.Format(time.RFC3339))
+		fmt.Printf("Run:          %s to %s, %d files, %d directories, %d failures\n",
+			info.Run.Start.Format(time.RFC3339), info.Run.End.Format(time.RFC3339),
+			info.Run.Files, info.Run.Directories, info.Run.Failures)
+	}
+
+	if len(problems) > 0 {
+		for _, problem := range problems {
+			fmt.Printf("INVALID: %s\n", problem)
+		}
+		return exitInvalid
+	}
+
+	fmt.Println("OK")
+	return exitOK
+}
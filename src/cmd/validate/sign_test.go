@@ -0,0 +1,113 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// signTestReport renders a small JSON report and signs it, returning the
+// report, its signature, and the signing key
+func signTestReport(t *testing.T) ([]byte, *ReportSignature, ed25519.PrivateKey) {
+	var _, key, err = ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Unable to generate key: %s", err)
+	}
+
+	opts.Format = "json"
+	var end = time.Now().Add(-time.Minute)
+	var r = &Report{Summary: Summary{Files: 3, Directories: 1, Failures: 2, Start: end.Add(-time.Second), End: end}}
+	var buf bytes.Buffer
+	err = writeJSONReport(&buf, r)
+	if err != nil {
+		t.Fatalf("Unable to write report: %s", err)
+	}
+
+	var dir string
+	dir, err = ioutil.TempDir("", "sign")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var sigFile = filepath.Join(dir, "report.json.sig")
+	err = signReport(buf.Bytes(), r, key, sigFile)
+	if err != nil {
+		t.Fatalf("Unable to sign report: %s", err)
+	}
+
+	var sigData []byte
+	sigData, err = ioutil.ReadFile(sigFile)
+	if err != nil {
+		t.Fatalf("Unable to read signature: %s", err)
+	}
+	var sig ReportSignature
+	err = json.Unmarshal(sigData, &sig)
+	if err != nil {
+		t.Fatalf("Unable to parse signature: %s", err)
+	}
+	return buf.Bytes(), &sig, key
+}
+
+func TestVerifyReport(t *testing.T) {
+	var data, sig, key = signTestReport(t)
+	var pub = key.Public().(ed25519.PublicKey)
+
+	var _, info, problems = verifyReport(data, sig, pub)
+	if len(problems) != 0 {
+		t.Errorf("Expected a valid signature, got problems: %s", strings.Join(problems, "; "))
+	}
+	if info == nil || info.Run.Files != 3 || info.ReportFormat != "json" {
+		t.Errorf("Expected signed run info to be returned, got %#v", info)
+	}
+}
+
+func TestVerifyReportTampered(t *testing.T) {
+	var data, sig, key = signTestReport(t)
+	var pub = key.Public().(ed25519.PublicKey)
+
+	var tampered = bytes.Replace(data, []byte(`"failures": 2`), []byte(`"failures": 0`), 1)
+	if bytes.Equal(tampered, data) {
+		t.Fatalf("Test report didn't contain the expected failure count")
+	}
+	var _, _, problems = verifyReport(tampered, sig, pub)
+	var joined = strings.Join(problems, "; ")
+	if !strings.Contains(joined, "modified since it was signed") || !strings.Contains(joined, "failure count") {
+		t.Errorf("Expected the tampered report to be caught, got problems: %s", joined)
+	}
+}
+
+func TestVerifyReportWrongKey(t *testing.T) {
+	var data, sig, _ = signTestReport(t)
+	var other, _, err = ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Unable to generate key: %s", err)
+	}
+
+	var _, _, problems = verifyReport(data, sig, other)
+	if len(problems) != 1 || !strings.Contains(problems[0], "trusted public key") {
+		t.Errorf("Expected only a trusted key problem, got %#v", problems)
+	}
+
+	// A report re-signed with someone else's key is internally consistent,
+	// but still isn't signed with the trusted key
+	var forgedData, forgedSig, _ = signTestReport(t)
+	_, _, problems = verifyReport(forgedData, forgedSig, key2pub(data, sig))
+	if len(problems) != 1 || !strings.Contains(problems[0], "trusted public key") {
+		t.Errorf("Expected a re-signed report to fail the trusted key check, got %#v", problems)
+	}
+}
+
+// key2pub returns the public key embedded in a signature, standing in for the
+// key an archive would pin
+func key2pub(data []byte, sig *ReportSignature) ed25519.PublicKey {
+	var pub, _, _ = verifyReport(data, sig, nil)
+	return pub
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/uoregon-libraries/dark-archive-validator/src/rules"
+)
+
+// printRunDetails writes the summary, waived findings, and baseline results
+// for the text report formats
+func printRunDetails(w io.Writer, r *Report) {
+	printSummary(w, r.Summary)
+	if r.TreeDigest != "" {
+		fmt.Fprintf(w, "Tree digest (%s): %s\n", r.ChecksumAlgorithm, r.TreeDigest)
+	}
+	if len(r.Waived) > 0 {
+		printWaived(w, r.Waived)
+	}
+	if r.Baseline != nil {
+		printBaselineReport(w, r.Baseline)
+	}
+}
+
+// printSummary writes a human-readable block of the run statistics
+func printSummary(w io.Writer, s Summary) {
+	fmt.Fprintln(w, "Summary:")
+	fmt.Fprintf(w, "  Files examined:        %d\n", s.Files)
+	fmt.Fprintf(w, "  Directories examined:  %d\n", s.Directories)
+	fmt.Fprintf(w, "  Total bytes:           %d\n", s.Bytes)
+	fmt.Fprintf(w, "  Paths with failures:   %d\n", s.FailedPaths)
+	fmt.Fprintf(w, "  Total failures:        %d\n", s.Failures)
+	fmt.Fprintf(w, "  Waived failures:       %d\n", s.Waived)
+	fmt.Fprintf(w, "  Duration:              %.3fs\n", s.DurationSeconds)
+	if s.ChecksumCache != nil {
+		fmt.Fprintf(w, "  Checksum cache:        %d hits, %d misses\n", s.ChecksumCache.Hits, s.ChecksumCache.Misses)
+	}
+	if s.ReclaimableBytes > 0 {
+		fmt.Fprintf(w, "  Reclaimable bytes:     %d (duplicate content)\n", s.ReclaimableBytes)
+	}
+
+	if s.Failures == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, "  Failures by criticality:")
+	for _, c := range []rules.Criticality{rules.CCritical, rules.CHigh, rules.CNormal, rules.CLow} {
+		if s.CriticalityFailures[c] > 0 {
+			fmt.Fprintf(w, "    %-24s %d\n", c, s.CriticalityFailures[c])
+		}
+	}
+
+	var names []string
+	for name := range s.ValidatorFailures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintln(w, "  Failures by validator:")
+	for _, name := range names {
+		fmt.Fprintf(w, "    %-24s %d\n", name, s.ValidatorFailures[name])
+	}
+}
+
+// printBaselineReport writes the counts of suppressed and fixed findings, and
+// lists the fixed findings so they can be checked off
+func printBaselineReport(w io.Writer, b *BaselineReport) {
+	fmt.Fprintf(w, "Baseline %s:\n", b.File)
+	fmt.Fprintf(w, "  Suppressed findings:   %d\n", b.Suppressed)
+	fmt.Fprintf(w, "  Fixed findings:        %d\n", len(b.Fixed))
+	for _, e := range b.Fixed {
+		fmt.Fprintf(w, "    %#v: %s (%s)\n", e.Path, e.Validator, e.Code)
+	}
+}
+
+// printWaived lists the findings which waivers kept out of the report
+func printWaived(w io.Writer, list []ReportWaived) {
+	fmt.Fprintln(w, "Waived findings:")
+	for _, rw := range list {
+		fmt.Fprintf(w, "  %#v: %s %s\n", rw.Path, rw.Validator, rw.Message)
+		fmt.Fprintf(w, "    approved by %s: %s", rw.ApprovedBy, rw.Justification)
+		if rw.Expires != "" {
+			fmt.Fprintf(w, " (expires %s)", rw.Expires)
+		}
+		fmt.Fprintln(w)
+	}
+}
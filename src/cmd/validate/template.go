@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"path/filepath"
+	"strings"
+	texttemplate "text/template"
+)
+
+// TemplateData is the data model for --template report templates.  Fields:
+//
+//	.RootPath    the absolute path which was validated
+//	.Options     the command-line options, e.g. .Options.Quick or
+//	             .Options.SkipList
+//	.Report      the same data the JSON format writes:
+//	  .Validators    list of validators run, each with .Name and .Criticality
+//	  .Failures      list of findings, each with .Path, .Validator,
+//	                 .Criticality, .Code, and .Message
+//	  .Waived        list of waived findings, each with the fields of a
+//	                 failure plus .ApprovedBy, .Justification, and .Expires
+//	  .Summary       run statistics: .Files, .Directories, .Bytes,
+//	                 .FailedPaths, .Failures, .Waived, .ValidatorFailures
+//	                 (map of validator name to count), .CriticalityFailures
+//	                 (map of criticality to count), .Start, .End, and
+//	                 .DurationSeconds, plus .ChecksumCache (.File, .Hits,
+//	                 and .Misses) when --checksum-cache is used, and
+//	                 .ReclaimableBytes, the total of .Duplicates'
+//	                 .Reclaimable
+//	  .Baseline      nil unless --baseline was used; otherwise .File,
+//	                 .Suppressed, and .Fixed (list with .Path, .Validator,
+//	                 and .Code)
+//	  .Checksums     map of path to checksum, empty for --quick runs
+//	  .ChecksumAlgorithm
+//	                 the --algorithm used for .Checksums
+//	  .TreeDigest    one digest of every file's content and path, empty
+//	                 unless every file was checksummed
+//	  .Digests       map of path to a map of algorithm to digest, holding
+//	                 any --extra-hash digests
+//	  .Duplicates    list of sets of files with the same content, largest
+//	                 waste first, each with .Checksum, .Size, .Paths, and
+//	                 .Reclaimable
+//	  .Provenance    run details: .Tool, .Host, .User, .Root, .Time,
+//	                 .RuleSetHash, and .Validators (list with .Name,
+//	                 .Criticality, .Priority, and .Params)
+//	  .Inventory     every path examined, only filled in when --inventory
+//	                 is used or the format is premis; each entry has
+//	                 .Path, .Type, .Size, .Modified, .Checksum, .Passed,
+//	                 and .Status
+//	  .Profile       per-validator timings, only filled in when
+//	                 --profile-rules is used; each has .Validator, .Calls,
+//	                 and .TotalSeconds, most expensive first
+//
+// Templates also have these functions available:
+//
+//	join     joins a list of strings: {{join .Options.SkipList ", "}}
+//	quote    quotes a string the way the TSV report does
+//
+// See templates/report.html.tmpl for an example.
+type TemplateData struct {
+	RootPath string
+	Options  interface{}
+	Report   *Report
+}
+
+var templateFuncs = map[string]interface{}{
+	"join":  strings.Join,
+	"quote": func(s string) string { return fmt.Sprintf("%#v", s) },
+}
+
+// reportTemplate is satisfied by both text and html templates
+type reportTemplate interface {
+	Execute(w io.Writer, data interface{}) error
+}
+
+// isHTMLTemplate returns true if the filename, ignoring any ".tmpl" suffix,
+// has an HTML extension, e.g., "report.html" or "report.html.tmpl"
+func isHTMLTemplate(filename string) bool {
+	var ext = filepath.Ext(strings.TrimSuffix(filename, ".tmpl"))
+	return ext == ".html" || ext == ".htm"
+}
+
+// parseTemplate reads a report template.  HTML templates use html/template so
+// paths and messages are escaped properly; all others use text/template.
+func parseTemplate(filename string) (reportTemplate, error) {
+	var name = filepath.Base(filename)
+	if isHTMLTemplate(filename) {
+		return htmltemplate.New(name).Funcs(templateFuncs).ParseFiles(filename)
+	}
+	return texttemplate.New(name).Funcs(templateFuncs).ParseFiles(filename)
+}
+
+// writeTemplateReport renders the report through the user's template
+func writeTemplateReport(w io.Writer, t reportTemplate, r *Report) error {
+	return t.Execute(w, TemplateData{RootPath: rootPath, Options: opts, Report: r})
+}
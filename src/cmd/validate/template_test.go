@@ -0,0 +1,61 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIsHTMLTemplate(t *testing.T) {
+	var tests = map[string]bool{
+		"report.html":      true,
+		"report.htm":       true,
+		"report.html.tmpl": true,
+		"report.txt.tmpl":  false,
+		"report.tmpl":      false,
+		"html":             false,
+	}
+	for name, expected := range tests {
+		if isHTMLTemplate(name) != expected {
+			t.Errorf("Expected isHTMLTemplate(%q) to be %v", name, expected)
+		}
+	}
+}
+
+func TestTemplateReport(t *testing.T) {
+	var dir, err = ioutil.TempDir("", "template")
+	if err != nil {
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	var savedRoot = rootPath
+	defer func() { rootPath = savedRoot }()
+	rootPath = "/archive"
+
+	var body = `{{range .Report.Failures}}{{.Path}} {{quote .Validator}} {{.Message}}{{end}} in {{.RootPath}}`
+	var r = &Report{Failures: []ReportFailure{{Path: "<b>&c", Validator: "no-bad-chars", Message: "has bad characters"}}}
+	var tests = map[string]string{
+		"report.txt.tmpl":  `<b>&c "no-bad-chars" has bad characters in /archive`,
+		"report.html.tmpl": `&lt;b&gt;&amp;c &#34;no-bad-chars&#34; has bad characters in /archive`,
+	}
+	for name, expected := range tests {
+		var fname = filepath.Join(dir, name)
+		ioutil.WriteFile(fname, []byte(body), 0644)
+
+		var tmpl, err = parseTemplate(fname)
+		if err != nil {
+			t.Fatalf("Unable to parse %s: %s", name, err)
+		}
+		var buf bytes.Buffer
+		err = writeTemplateReport(&buf, tmpl, r)
+		if err != nil {
+			t.Fatalf("Unable to render %s: %s", name, err)
+		}
+		if buf.String() != expected {
+			t.Errorf("Expected %s to render %q, got %q", name, expected, buf.String())
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/uoregon-libraries/dark-archive-validator/src/checksum"
+)
+
+// rateSuffixes maps the --max-read-rate suffixes to their multipliers
+var rateSuffixes = map[string]int64{"": 1, "K": 1 << 10, "M": 1 << 20, "G": 1 << 30}
+
+// parseByteRate reads a rate such as "500K", "50M", or "1G".  A trailing "B"
+// or "/s" is allowed, so "50MB/s" works as well.  Rates are whole bytes per
+// second, so anything under one byte is rejected rather than being
+// truncated to zero, which would mean no limit at all.
+func parseByteRate(s string) (int64, error) {
+	var spec = strings.ToUpper(strings.TrimSpace(s))
+	spec = strings.TrimSuffix(spec, "/S")
+	spec = strings.TrimSuffix(spec, "B")
+	var unit string
+	if spec != "" {
+		var last = spec[len(spec)-1:]
+		if _, ok := rateSuffixes[last]; ok {
+			unit = last
+			spec = spec[:len(spec)-1]
+		}
+	}
+
+	var n, err = strconv.ParseFloat(spec, 64)
+	if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("invalid read rate %q; expected something like 50M", s)
+	}
+	n *= float64(rateSuffixes[unit])
+	if n < 1 || n > math.MaxInt64 {
+		return 0, fmt.Errorf("invalid read rate %q; must be at least one byte per second", s)
+	}
+	return int64(n), nil
+}
+
+// setReadLimit sets up the checksum package's read limit from the
+// --max-read-rate and --throttle-hours options
+func setReadLimit(rate, hours string) error {
+	if rate == "" {
+		if hours != "" {
+			return fmt.Errorf("--throttle-hours requires --max-read-rate")
+		}
+		return nil
+	}
+
+	var n, err = parseByteRate(rate)
+	if err != nil {
+		return err
+	}
+	var l = checksum.NewRateLimiter(n)
+	if hours != "" {
+		l.Window, err = checksum.ParseWindow(hours)
+		if err != nil {
+			return err
+		}
+	}
+	checksum.ReadLimit = l
+	return nil
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseByteRate(t *testing.T) {
+	var good = map[string]int64{
+		"500":    500,
+		"1.5":    1,
+		"500K":   500 << 10,
+		"50MB/s": 50 << 20,
+		"0.5K":   512,
+		"1g":     1 << 30,
+	}
+	for s, expected := range good {
+		var n, err = parseByteRate(s)
+		if err != nil || n != expected {
+			t.Errorf("Expected %q to parse as %d, got %d (error: %v)", s, expected, n, err)
+		}
+	}
+
+	for _, s := range []string{"", "0", "0.5", "0.0001K", "-5M", "fast", "inf", "NaN"} {
+		var n, err = parseByteRate(s)
+		if err == nil {
+			t.Errorf("Expected %q to be rejected, got %d", s, n)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// treeNode is one path component in the tree report.  Failures are those
+// reported on this exact path; total counts failures here and below.
+type treeNode struct {
+	name     string
+	children map[string]*treeNode
+	failures []ReportFailure
+	total    int
+}
+
+func newTreeNode(name string) *treeNode {
+	return &treeNode{name: name, children: make(map[string]*treeNode)}
+}
+
+// buildTree nests the report's failures under their parent directories
+func buildTree(r *Report) *treeNode {
+	var root = newTreeNode(rootPath)
+	for _, f := range r.Failures {
+		var n = root
+		n.total++
+		for _, part := range strings.Split(f.Path, string(filepath.Separator)) {
+			if n.children[part] == nil {
+				n.children[part] = newTreeNode(part)
+			}
+			n = n.children[part]
+			n.total++
+		}
+		n.failures = append(n.failures, f)
+	}
+
+	return root
+}
+
+// signature describes a node's failures so identical leaves can be grouped
+func (n *treeNode) signature() string {
+	var parts []string
+	for _, f := range n.failures {
+		parts = append(parts, f.Validator+"\x00"+f.Code+"\x00"+f.Message)
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, "\x00\x00")
+}
+
+// sortedChildren returns the node's children ordered by name
+func (n *treeNode) sortedChildren() []*treeNode {
+	var list []*treeNode
+	for _, c := range n.children {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].name < list[j].name })
+	return list
+}
+
+// displayName returns s as-is if it's printable, or quoted otherwise
+func displayName(s string) string {
+	var q = strconv.Quote(s)
+	if q[1:len(q)-1] == s {
+		return s
+	}
+	return q
+}
+
+// pluralize returns "1 <singular>" or "n <plural>" depending on n
+func pluralize(n int, singular, plural string) string {
+	if n == 1 {
+		return fmt.Sprintf("%d %s", n, singular)
+	}
+	return fmt.Sprintf("%d %s", n, plural)
+}
+
+// writeTreeReport prints failures nested under their directories.  Sibling
+// files with identical failures are collapsed into a single entry when there
+// are at least collapse of them; zero disables collapsing.
+func writeTreeReport(w io.Writer, r *Report, collapse int) {
+	var root = buildTree(r)
+	fmt.Fprintf(w, "%s [%s]\n", displayName(root.name), pluralize(root.total, "failure", "failures"))
+	writeTreeChildren(w, root, 1, collapse)
+}
+
+func writeTreeFailures(w io.Writer, list []ReportFailure, depth int) {
+	var indent = strings.Repeat("  ", depth)
+	for _, f := range list {
+		fmt.Fprintf(w, "%s! %s: %s\n", indent, f.Validator, f.Message)
+	}
+}
+
+func writeTreeChildren(w io.Writer, n *treeNode, depth int, collapse int) {
+	var indent = strings.Repeat("  ", depth)
+	var children = n.sortedChildren()
+
+	// Group leaves by their failures.  A collapsed group is printed where its
+	// first entry would have been, so output stays sorted by name.
+	var groups = make(map[string][]*treeNode)
+	for _, c := range children {
+		if len(c.children) == 0 {
+			var sig = c.signature()
+			groups[sig] = append(groups[sig], c)
+		}
+	}
+
+	var collapsed = make(map[*treeNode][]*treeNode)
+	for _, group := range groups {
+		if collapse <= 0 || len(group) < collapse {
+			continue
+		}
+		for _, c := range group {
+			collapsed[c] = group
+		}
+	}
+
+	for _, c := range children {
+		if group := collapsed[c]; group != nil {
+			if group[0] == c {
+				writeTreeGroup(w, group, depth)
+			}
+			continue
+		}
+		if len(c.children) == 0 {
+			fmt.Fprintf(w, "%s%s\n", indent, displayName(c.name))
+			writeTreeFailures(w, c.failures, depth+1)
+			continue
+		}
+
+		fmt.Fprintf(w, "%s%s%c [%s]\n", indent, displayName(c.name), filepath.Separator, pluralize(c.total, "failure", "failures"))
+		writeTreeFailures(w, c.failures, depth+1)
+		writeTreeChildren(w, c, depth+1, collapse)
+	}
+}
+
+// writeTreeGroup prints a collapsed group of sibling leaves which all have the
+// same failures
+func writeTreeGroup(w io.Writer, group []*treeNode, depth int) {
+	var examples []string
+	for i := 0; i < len(group) && i < 3; i++ {
+		examples = append(examples, displayName(group[i].name))
+	}
+	fmt.Fprintf(w, "%s%s with the same failures, e.g. %s\n", strings.Repeat("  ", depth),
+		pluralize(len(group), "entry", "entries"), strings.Join(examples, ", "))
+	writeTreeFailures(w, group[0].failures, depth+1)
+}
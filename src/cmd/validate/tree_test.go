@@ -0,0 +1,37 @@
+package main
+
+import (
+	"os"
+)
+
+func Example_writeTreeReport() {
+	var savedRoot = rootPath
+	defer func() { rootPath = savedRoot }()
+	rootPath = "/archive"
+
+	var space = func(path string) ReportFailure {
+		return ReportFailure{Path: path, Validator: "no-spaces", Code: "space", Message: "has spaces"}
+	}
+	var r = &Report{Failures: []ReportFailure{
+		{Path: "b-file", Validator: "no-bad-chars", Code: "bad-char", Message: "has bad characters"},
+		space("c d"),
+		space("dir/x y"),
+		{Path: "e\tf", Validator: "no-bad-chars", Code: "bad-char", Message: "has bad characters"},
+		space("g h"),
+		space("k l"),
+	}}
+
+	writeTreeReport(os.Stdout, r, 3)
+
+	// Output:
+	// /archive [6 failures]
+	//   b-file
+	//     ! no-bad-chars: has bad characters
+	//   3 entries with the same failures, e.g. c d, g h, k l
+	//     ! no-spaces: has spaces
+	//   dir/ [1 failure]
+	//     x y
+	//       ! no-spaces: has spaces
+	//   "e\tf"
+	//     ! no-bad-chars: has bad characters
+}
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jessevdk/go-flags"
+	"github.com/uoregon-libraries/dark-archive-validator/src/checksum"
+	"github.com/uoregon-libraries/dark-archive-validator/src/rules"
+)
+
+var verifyOpts struct {
+	Algorithm  string `long:"algorithm" description:"Hash algorithm the manifest uses; by default this is taken from the manifest's filename (e.g., manifest-md5.txt or SHA256SUMS) or guessed from the digest length" choice:"md5" choice:"sha1" choice:"sha256" choice:"sha512" choice:"blake2b"`
+	Format     string `short:"f" long:"format" description:"Report format; the run summary is printed to stderr for tsv and embedded in the report for json" choice:"tsv" choice:"json" default:"tsv"`
+	ReportFile string `long:"report-file" description:"Write the report to this file instead of stdout"`
+}
+
+// digestLengths maps hex digest lengths to the algorithm we assume produced
+// them.  SHA-512 and BLAKE2b digests are the same length, so BLAKE2b
+// manifests need --algorithm or a descriptive filename.
+var digestLengths = map[int]string{32: "md5", 40: "sha1", 64: "sha256", 128: "sha512"}
+
+// manifestAlgorithm figures out which algorithm a manifest uses: the name in
+// its filename if there is one, otherwise whatever its digests' length
+// implies
+func manifestAlgorithm(fname string, sums map[string]string) (string, error) {
+	var base = strings.ToLower(filepath.Base(fname))
+	for _, name := range checksum.AlgorithmNames() {
+		if strings.Contains(base, name) {
+			return name, nil
+		}
+	}
+
+	var alg string
+	for _, sum := range sums {
+		var guess = digestLengths[len(sum)]
+		if guess == "" || (alg != "" && guess != alg) {
+			return "", fmt.Errorf("unable to determine the manifest's algorithm from its filename or digests")
+		}
+		alg = guess
+	}
+	if alg == "" {
+		return "", fmt.Errorf("manifest is empty")
+	}
+	return alg, nil
+}
+
+// readVerifyManifest loads a manifest and normalizes its paths to be relative
+// to the root, using forward slashes.  Absolute paths, such as those
+// --checksum-output writes, must be under the root.
+func readVerifyManifest(fname string) (map[string]string, error) {
+	var f, err = os.Open(fname)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var raw map[string]string
+	raw, err = checksum.ReadManifest(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", fname, err)
+	}
+
+	var sums = make(map[string]string)
+	for path, sum := range raw {
+		path = filepath.FromSlash(path)
+		if filepath.IsAbs(path) {
+			var rel, err = filepath.Rel(rootPath, path)
+			if err != nil || strings.HasPrefix(rel, "..") {
+				return nil, fmt.Errorf("%s: %q is not under %q", fname, path, rootPath)
+			}
+			path = rel
+		}
+		sums[filepath.ToSlash(filepath.Clean(path))] = sum
+	}
+	return sums, nil
+}
+
+// runVerify implements "validate verify", rehashing a tree and comparing it
+// to a manifest
+func runVerify(args []string) int {
+	var p = flags.NewParser(&verifyOpts, flags.HelpFlag)
+	p.Usage = "verify [OPTIONS] <manifest> <path to verify>"
+	p.LongDescription = "Rehashes every file under the given path and compares it to a " +
+		"sha256sum-style manifest, reporting files which are missing, files the " +
+		"manifest doesn't list, and files whose checksums don't match.  Exits 0 if " +
+		"everything matched, 1 on usage or runtime errors, and 2 if there were " +
+		"any discrepancies."
+
+	var more, err = p.ParseArgs(args)
+	if err == nil && len(more) != 2 {
+		err = fmt.Errorf("must specify a manifest and a path to verify")
+	}
+	if err != nil {
+		subcommandUsage(p, err)
+	}
+
+	getRootPath(more[1])
+	var info os.FileInfo
+	info, err = os.Stat(rootPath)
+	if err == nil && !info.IsDir() {
+		err = fmt.Errorf("%s is not a directory", rootPath)
+	}
+	if err != nil {
+		subcommandUsage(p, err)
+	}
+
+	var manifest map[string]string
+	manifest, err = readVerifyManifest(more[0])
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "ERROR: %s\n", err)
+		return exitError
+	}
+
+	var alg = verifyOpts.Algorithm
+	if alg == "" {
+		alg, err = manifestAlgorithm(more[0], manifest)
+		if err != nil {
+			subcommandUsage(p, fmt.Errorf("%s; use --algorithm", err))
+		}
+	}
+
+	// Only the manifest check runs; the naming rules are for "validate"
+	rules.NukeValidatorList()
+	var c, _ = checksum.NewAlgorithm(alg)
+	var check = rules.RegisterManifestValidator(rootPath, c, manifest)
+
+	engine = rules.NewEngine()
+	engine.Tool = toolName + " " + version
+	getAllValidators()
+	engine.ValidateTree(rootPath, failfunc)
+	check.ReportMissing(engine, failfunc)
+
+	// The report writer works from the main command's options
+	opts.Format = verifyOpts.Format
+	opts.ReportFile = verifyOpts.ReportFile
+	var report = buildReport()
+	writeReport(report)
+
+	if report.Summary.Failures > 0 {
+		return exitInvalid
+	}
+	return exitOK
+}
@@ -0,0 +1,8 @@
+package main
+
+// toolName identifies this program in reports and preservation metadata
+const toolName = "dark-archive-validator"
+
+// version is set at build time via "-ldflags -X main.version=..."; the
+// Makefile uses "git describe"
+var version = "development"
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"io"
+	"time"
+
+	"github.com/uoregon-libraries/dark-archive-validator/src/rules"
+	"github.com/uoregon-libraries/dark-archive-validator/src/xlsx"
+)
+
+// writeXLSXReport writes the report as a spreadsheet with summary and
+// provenance sheets, a sheet with one row per failure, and sheets of waived
+// findings and duplicate files if there are any
+func writeXLSXReport(w io.Writer, r *Report) error {
+	var wb = xlsx.New()
+	addSummarySheet(wb, r)
+	addProvenanceSheet(wb, r.Provenance)
+
+	var fs = wb.AddSheet("Failures")
+	fs.Header = true
+	fs.AddRow("Path", "Validator", "Criticality", "Code", "Message")
+	for _, f := range r.Failures {
+		fs.AddRow(f.Path, f.Validator, f.Criticality.String(), f.Code, f.Message)
+	}
+	setColumnWidths(fs, 60, 24, 12, 24, 60)
+
+	if len(r.Waived) > 0 {
+		var ws = wb.AddSheet("Waived")
+		ws.Header = true
+		ws.AddRow("Path", "Validator", "Criticality", "Code", "Message", "Approved By", "Justification", "Expires")
+		for _, f := range r.Waived {
+			ws.AddRow(f.Path, f.Validator, f.Criticality.String(), f.Code, f.Message, f.ApprovedBy, f.Justification, f.Expires)
+		}
+		setColumnWidths(ws, 60, 24, 12, 24, 60, 20, 60, 12)
+	}
+
+	if len(r.Duplicates) > 0 {
+		var ds = wb.AddSheet("Duplicates")
+		ds.Header = true
+		ds.AddRow("Set", "Checksum", "Size", "Copies", "Reclaimable", "Path")
+		for i, set := range r.Duplicates {
+			for _, path := range set.Paths {
+				ds.AddRow(i+1, set.Checksum, set.Size, len(set.Paths), set.Reclaimable, path)
+			}
+		}
+		setColumnWidths(ds, 6, 40, 14, 8, 14, 60)
+	}
+
+	return wb.Write(w)
+}
+
+func addSummarySheet(wb *xlsx.Workbook, r *Report) {
+	var s = r.Summary
+	var ss = wb.AddSheet("Summary")
+	ss.Header = true
+	ss.AddRow("Item", "Value")
+	ss.AddRow("Root path", rootPath)
+	ss.AddRow("Files examined", s.Files)
+	ss.AddRow("Directories examined", s.Directories)
+	ss.AddRow("Total bytes", s.Bytes)
+	ss.AddRow("Paths with failures", s.FailedPaths)
+	ss.AddRow("Total failures", s.Failures)
+	ss.AddRow("Waived failures", s.Waived)
+	ss.AddRow("Reclaimable duplicate bytes", s.ReclaimableBytes)
+	if r.TreeDigest != "" {
+		ss.AddRow("Tree digest ("+r.ChecksumAlgorithm+")", r.TreeDigest)
+	}
+	ss.AddRow("Start", s.Start.Format(time.RFC3339))
+	ss.AddRow("End", s.End.Format(time.RFC3339))
+	ss.AddRow("Duration (seconds)", s.DurationSeconds)
+	if s.ChecksumCache != nil {
+		ss.AddRow("Checksum cache hits", s.ChecksumCache.Hits)
+		ss.AddRow("Checksum cache misses", s.ChecksumCache.Misses)
+	}
+
+	for _, c := range []rules.Criticality{rules.CCritical, rules.CHigh, rules.CNormal, rules.CLow} {
+		ss.AddRow(c.String()+" failures", s.CriticalityFailures[c])
+	}
+	for _, v := range r.Validators {
+		ss.AddRow("Failures: "+v.Name, s.ValidatorFailures[v.Name])
+	}
+	setColumnWidths(ss, 36, 60)
+}
+
+func addProvenanceSheet(wb *xlsx.Workbook, p *rules.Provenance) {
+	var ps = wb.AddSheet("Provenance")
+	ps.Header = true
+	ps.AddRow("Item", "Value")
+	ps.AddRow("Tool", p.Tool)
+	ps.AddRow("Host", p.Host)
+	ps.AddRow("User", p.User)
+	ps.AddRow("Root path", p.Root)
+	ps.AddRow("Time", p.Time.Format(time.RFC3339))
+	ps.AddRow("Rule set hash", p.RuleSetHash)
+	for _, vi := range p.Validators {
+		ps.AddRow("Validator", validatorDescription(vi))
+	}
+	setColumnWidths(ps, 20, 80)
+}
+
+func setColumnWidths(s *xlsx.Sheet, widths ...float64) {
+	for i, width := range widths {
+		s.SetColumnWidth(i, width)
+	}
+}
@@ -0,0 +1,116 @@
+package rules
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/uoregon-libraries/dark-archive-validator/src/checksum"
+)
+
+// ArchiveIndex records the content already preserved in the dark archive:
+// for each algorithm, a map of digest to the locations holding that content
+type ArchiveIndex struct {
+	digests map[string]map[string][]string
+}
+
+// NewArchiveIndex returns an empty index
+func NewArchiveIndex() *ArchiveIndex {
+	return &ArchiveIndex{digests: make(map[string]map[string][]string)}
+}
+
+// Add records that the content with the given digest lives at location
+func (idx *ArchiveIndex) Add(alg, digest, location string) error {
+	if checksum.Algorithms[alg] == nil {
+		return fmt.Errorf("unknown algorithm %q", alg)
+	}
+	if idx.digests[alg] == nil {
+		idx.digests[alg] = make(map[string][]string)
+	}
+	digest = strings.ToLower(digest)
+	idx.digests[alg][digest] = append(idx.digests[alg][digest], location)
+	return nil
+}
+
+// AddManifest records every entry of a manifest read by
+// checksum.ReadManifest.  Relative paths are taken to be relative to the
+// manifest's directory, as they are in a BagIt bag.
+func (idx *ArchiveIndex) AddManifest(alg, fname string, manifest map[string]string) error {
+	var dir = filepath.Dir(fname)
+	for path, digest := range manifest {
+		var location = filepath.FromSlash(path)
+		if !filepath.IsAbs(location) {
+			location = filepath.Join(dir, location)
+		}
+		var err = idx.Add(alg, digest, location)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Len returns the number of locations in the index
+func (idx *ArchiveIndex) Len() int {
+	var n int
+	for _, sums := range idx.digests {
+		for _, locations := range sums {
+			n += len(locations)
+		}
+	}
+	return n
+}
+
+// algorithms returns the sorted names of the algorithms the index uses
+func (idx *ArchiveIndex) algorithms() []string {
+	var names []string
+	for name := range idx.digests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RegisterArchiveIndexValidator registers "not-already-archived", which fails
+// any file whose content is in the index, listing where it's preserved.  When
+// store is shared with the checksum validator and the index uses one of its
+// algorithms, files aren't read a second time.
+func RegisterArchiveIndexValidator(root string, idx *ArchiveIndex, store *SumStore) {
+	var algs = idx.algorithms()
+	var validateArchived = func(path string, info os.FileInfo) error {
+		if !info.Mode().IsRegular() || len(algs) == 0 {
+			return nil
+		}
+
+		var fullPath = filepath.Join(root, path)
+		var sums, err = store.sumAlgorithms(fullPath, info, algs)
+		if err != nil {
+			return newError("checksum-failed", Params{"error": err})
+		}
+
+		var seen = make(map[string]bool)
+		var locations []string
+		for _, alg := range algs {
+			for _, loc := range idx.digests[alg][sums[alg]] {
+				if !seen[loc] {
+					seen[loc] = true
+					locations = append(locations, quoted(loc))
+				}
+			}
+		}
+		if len(locations) == 0 {
+			return nil
+		}
+		sort.Strings(locations)
+		return newError("already-archived", Params{"locations": strings.Join(locations, ", ")})
+	}
+
+	register(Validator{
+		Name:        "not-already-archived",
+		vf:          validateArchived,
+		Criticality: CNormal,
+		params:      Params{"algorithms": strings.Join(algs, ","), "entries": idx.Len()},
+	})
+}
@@ -0,0 +1,58 @@
+package rules
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestArchiveIndexValidator(t *testing.T) {
+	var root, cleanup = newTestTree(t, "archiveindex")
+	defer cleanup()
+
+	writeTestFile(t, root, "old.txt", "already preserved")
+	writeTestFile(t, root, "new.txt", "brand new")
+
+	var idx = NewArchiveIndex()
+	var err = idx.AddManifest("md5", "/archive/bag1/manifest-md5.txt", map[string]string{
+		"data/old.txt": md5hex("already preserved"),
+		"data/foo.txt": md5hex("something else"),
+	})
+	if err != nil {
+		t.Fatalf("Unable to add manifest: %s", err)
+	}
+	idx.Add("md5", md5hex("already preserved"), "/archive/bag2/data/copy.txt")
+	if idx.Len() != 3 {
+		t.Errorf("Expected 3 index entries, got %d", idx.Len())
+	}
+	if idx.Add("crc32", "abcd", "x") == nil {
+		t.Errorf("Expected an unknown algorithm to be rejected")
+	}
+
+	var read []string
+	var c = recordingMD5(&read)
+	var store = NewSumStore(nil)
+	RegisterChecksumValidator(root, c, make(map[string][]string), nil, store)
+	RegisterArchiveIndexValidator(root, idx, store)
+
+	var got = make(map[string]string)
+	var e = NewEngine()
+	e.ValidateTree(root, func(path string, fl []Failure) {
+		for _, f := range fl {
+			got[filepath.ToSlash(path)] = f.E.Error()
+		}
+	})
+
+	var expected = `has content already preserved in the dark archive at "/archive/bag1/data/old.txt", "/archive/bag2/data/copy.txt"`
+	if got["old.txt"] != expected {
+		t.Errorf("Expected old.txt to fail with %q, got %q", expected, got["old.txt"])
+	}
+	if len(got) != 1 {
+		t.Errorf("Expected only old.txt to fail, got %#v", got)
+	}
+
+	// The index uses the checksum validator's algorithm and they share a
+	// store, so each file is read only once
+	if len(read) != 2 {
+		t.Errorf("Expected 2 file reads, got %d", len(read))
+	}
+}
@@ -0,0 +1,306 @@
+package rules
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/uoregon-libraries/dark-archive-validator/src/checksum"
+)
+
+// BagCheck validates a BagIt bag.  Its validator checks each payload file
+// against every payload manifest as the engine walks data/; Finish then
+// reports everything which can only be known once the walk is done, along
+// with any problems found in the tag files.
+type BagCheck struct {
+	root         string
+	v            Validator
+	store        *SumStore
+	algs         []string
+	manifests    map[string]map[string]string
+	tagManifests map[string]map[string]string
+	oxum         string
+	problems     []bagProblem
+	seen         map[string]bool
+	bytes        int64
+	files        int
+}
+
+// bagProblem is a failure found in the bag's tag files before the walk
+type bagProblem struct {
+	path string
+	err  *Error
+}
+
+// RegisterBagValidator reads the tag files of the bag at root and registers
+// "bagit-payload" to check each payload file.  The validator runs before all
+// others so that every payload file is counted even when other validators
+// stop on failure.  Payload files are always read, even if store's cache
+// knows them, since fixity has to be checked against their actual bytes, but
+// the digests go into store so other validators using it needn't read the
+// files again.  Use the BagCheck's TraverseFn so only data/ is walked, and
+// call Finish after the walk.
+func RegisterBagValidator(root string, store *SumStore) *BagCheck {
+	var b = &BagCheck{
+		root:         root,
+		store:        store,
+		manifests:    make(map[string]map[string]string),
+		tagManifests: make(map[string]map[string]string),
+		seen:         make(map[string]bool),
+	}
+	b.readDeclaration()
+	b.readBagInfo()
+	b.readManifests()
+
+	b.v = Validator{Name: "bagit-payload", vf: b.validatePayload, priority: -127, Criticality: CCritical}
+	for alg := range b.manifests {
+		b.algs = append(b.algs, alg)
+	}
+	sort.Strings(b.algs)
+	if len(b.algs) > 0 {
+		b.v.params = Params{"algorithms": strings.Join(b.algs, ",")}
+	}
+	register(b.v)
+	return b
+}
+
+// TraverseFn walks only the bag's payload directory, so the naming rules
+// never see tag files
+func (b *BagCheck) TraverseFn(root string, walkfn filepath.WalkFunc) error {
+	return filepath.Walk(filepath.Join(root, "data"), walkfn)
+}
+
+func (b *BagCheck) problem(path, code string, params Params) {
+	b.problems = append(b.problems, bagProblem{path, newError(code, params)})
+}
+
+// readTagFile returns the lines of a tag file, or nil if it doesn't exist
+func (b *BagCheck) readTagFile(name string, required bool) []string {
+	var f, err = os.Open(filepath.Join(b.root, name))
+	if os.IsNotExist(err) {
+		if required {
+			b.problem(name, "bag-missing-tag-file", nil)
+		}
+		return nil
+	}
+	if err != nil {
+		b.problem(name, "unreadable", Params{"error": err})
+		return nil
+	}
+	defer f.Close()
+
+	var lines []string
+	var s = bufio.NewScanner(f)
+	for s.Scan() {
+		lines = append(lines, strings.TrimRight(s.Text(), "\r"))
+	}
+	if s.Err() != nil {
+		b.problem(name, "unreadable", Params{"error": s.Err()})
+	}
+	return lines
+}
+
+// tagFields parses "Label: Value" lines into a map.  Continuation lines,
+// which start with whitespace, are ignored.
+func tagFields(lines []string) map[string]string {
+	var fields = make(map[string]string)
+	for _, line := range lines {
+		if line == "" || line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		var parts = strings.SplitN(line, ":", 2)
+		if len(parts) == 2 {
+			fields[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+		}
+	}
+	return fields
+}
+
+func (b *BagCheck) readDeclaration() {
+	var lines = b.readTagFile("bagit.txt", true)
+	if lines == nil {
+		return
+	}
+	var fields = tagFields(lines)
+	if fields["BagIt-Version"] == "" || fields["Tag-File-Character-Encoding"] == "" {
+		b.problem("bagit.txt", "bag-invalid-declaration", nil)
+	}
+}
+
+func (b *BagCheck) readBagInfo() {
+	b.oxum = tagFields(b.readTagFile("bag-info.txt", false))["Payload-Oxum"]
+}
+
+// readManifests loads every manifest-<algorithm>.txt and
+// tagmanifest-<algorithm>.txt in the bag
+func (b *BagCheck) readManifests() {
+	var names, _ = filepath.Glob(filepath.Join(b.root, "*manifest-*.txt"))
+	for _, fullPath := range names {
+		var name = filepath.Base(fullPath)
+		var target = b.manifests
+		var alg = strings.TrimSuffix(strings.TrimPrefix(name, "manifest-"), ".txt")
+		if strings.HasPrefix(name, "tagmanifest-") {
+			target = b.tagManifests
+			alg = strings.TrimSuffix(strings.TrimPrefix(name, "tagmanifest-"), ".txt")
+		} else if !strings.HasPrefix(name, "manifest-") {
+			continue
+		}
+
+		if _, ok := checksum.Algorithms[alg]; !ok {
+			b.problem(name, "bag-unknown-algorithm", Params{"algorithm": alg})
+			continue
+		}
+
+		var f, err = os.Open(fullPath)
+		if err != nil {
+			b.problem(name, "unreadable", Params{"error": err})
+			continue
+		}
+		var sums map[string]string
+		sums, err = checksum.ReadManifest(f)
+		f.Close()
+		if err != nil {
+			b.problem(name, "unreadable", Params{"error": err})
+			continue
+		}
+
+		target[alg] = make(map[string]string)
+		for path, sum := range sums {
+			target[alg][decodeBagPath(path)] = sum
+		}
+	}
+
+	if len(b.manifests) == 0 {
+		b.problem("manifest-*.txt", "bag-missing-tag-file", nil)
+	}
+}
+
+// decodeBagPath undoes the percent-encoding BagIt requires in manifest paths
+func decodeBagPath(path string) string {
+	return strings.NewReplacer("%0D", "\r", "%0d", "\r", "%0A", "\n", "%0a", "\n", "%25", "%").Replace(path)
+}
+
+// validatePayload checks one payload file against every payload manifest.
+// Only the first problem found is returned, checking manifests in
+// alphabetical order.
+func (b *BagCheck) validatePayload(path string, info os.FileInfo) error {
+	if !info.Mode().IsRegular() {
+		return nil
+	}
+
+	var key = filepath.ToSlash(path)
+	b.seen[key] = true
+	b.files++
+	b.bytes += info.Size()
+	if len(b.algs) == 0 {
+		return nil
+	}
+
+	var sums, err = b.store.verifyAlgorithms(filepath.Join(b.root, path), info, b.algs)
+	if err != nil {
+		return newError("checksum-failed", Params{"error": err})
+	}
+
+	for _, alg := range b.algs {
+		var manifest = "manifest-" + alg + ".txt"
+		var expected, listed = b.manifests[alg][key]
+		if !listed {
+			return newError("bag-not-in-manifest", Params{"manifest": manifest})
+		}
+		var actual = sums[alg]
+		if actual != expected {
+			return newError("bag-checksum-mismatch", Params{"manifest": manifest, "actual": actual, "expected": expected})
+		}
+	}
+	return nil
+}
+
+// Finish reports the tag file problems, payload files listed in a manifest
+// but never seen, a Payload-Oxum which doesn't match the payload, and tag
+// files which don't match their tag manifests
+func (b *BagCheck) Finish(e *Engine, failFunc func(string, []Failure)) {
+	var report = func(path string, err *Error) {
+		e.AddFailures(filepath.FromSlash(path), []Failure{{V: b.v, E: err}}, failFunc)
+	}
+
+	for _, p := range b.problems {
+		report(p.path, p.err)
+	}
+
+	var missing = make(map[string]string)
+	for _, alg := range b.algs {
+		for path := range b.manifests[alg] {
+			if !b.seen[path] && missing[path] == "" {
+				missing[path] = "manifest-" + alg + ".txt"
+			}
+		}
+	}
+	var paths []string
+	for path := range missing {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		report(path, newError("bag-missing-file", Params{"manifest": missing[path]}))
+	}
+
+	var oxum = fmt.Sprintf("%d.%d", b.bytes, b.files)
+	if b.oxum != "" && b.oxum != oxum {
+		report("bag-info.txt", newError("bag-oxum-mismatch", Params{"expected": b.oxum, "actual": oxum}))
+	}
+
+	b.checkTagManifests(report)
+}
+
+func (b *BagCheck) checkTagManifests(report func(string, *Error)) {
+	var algs []string
+	for alg := range b.tagManifests {
+		algs = append(algs, alg)
+	}
+	sort.Strings(algs)
+
+	for _, alg := range algs {
+		var manifest = "tagmanifest-" + alg + ".txt"
+		var c, _ = checksum.NewAlgorithm(alg)
+		var paths []string
+		for path := range b.tagManifests[alg] {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+
+		for _, path := range paths {
+			if !insideBag(path) {
+				report(path, newError("bag-unsafe-path", Params{"manifest": manifest}))
+				continue
+			}
+			var sum, err = c.Sum(filepath.Join(b.root, filepath.FromSlash(path)))
+			if os.IsNotExist(err) {
+				report(path, newError("bag-missing-file", Params{"manifest": manifest}))
+				continue
+			}
+			if err != nil && err != io.EOF {
+				report(path, newError("checksum-failed", Params{"error": err}))
+				continue
+			}
+			var actual = fmt.Sprintf("%x", sum)
+			if actual != b.tagManifests[alg][path] {
+				report(path, newError("bag-checksum-mismatch", Params{"manifest": manifest, "actual": actual, "expected": b.tagManifests[alg][path]}))
+			}
+		}
+	}
+}
+
+// insideBag returns true if the manifest path is relative and stays within
+// the bag once cleaned, so it's safe to join to the bag's root
+func insideBag(path string) bool {
+	var p = filepath.FromSlash(path)
+	if filepath.IsAbs(p) || filepath.VolumeName(p) != "" {
+		return false
+	}
+	p = filepath.Clean(p)
+	return p != ".." && !strings.HasPrefix(p, ".."+string(filepath.Separator))
+}
@@ -0,0 +1,140 @@
+package rules
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/uoregon-libraries/dark-archive-validator/src/checksum"
+)
+
+func TestBagCheck(t *testing.T) {
+	var root, cleanup = newTestTree(t, "bagcheck")
+	defer cleanup()
+
+	var bagit = "BagIt-Version: 1.0\nTag-File-Character-Encoding: UTF-8\n"
+	writeTestFile(t, root, "bagit.txt", bagit)
+	writeTestFile(t, root, "bag-info.txt", "Payload-Oxum: 99.2\n")
+	writeTestFile(t, root, "data/good.txt", "good")
+	writeTestFile(t, root, "data/bad.txt", "bad")
+	writeTestFile(t, root, "data/extra.txt", "extra")
+	writeTestFile(t, root, "manifest-md5.txt", md5hex("good")+"  data/good.txt\n"+
+		md5hex("changed")+"  data/bad.txt\n"+
+		md5hex("gone")+"  data/gone.txt\n")
+	writeTestFile(t, root, "tagmanifest-md5.txt", md5hex(bagit)+"  bagit.txt\n"+
+		md5hex("wrong")+"  bag-info.txt\n"+
+		md5hex("secret")+"  ../outside.txt\n"+
+		md5hex("secret")+"  /etc/passwd\n")
+
+	var b = RegisterBagValidator(root, nil)
+	var e = NewEngine()
+	e.TraverseFn = b.TraverseFn
+	var got = make(map[string]string)
+	var failFunc = func(path string, fl []Failure) {
+		for _, f := range fl {
+			var key = filepath.ToSlash(path)
+			if got[key] != "" {
+				got[key] += ","
+			}
+			got[key] += f.Code()
+		}
+	}
+	e.ValidateTree(root, failFunc)
+	b.Finish(e, failFunc)
+
+	var expected = map[string]string{
+		"data/bad.txt":   "bag-checksum-mismatch",
+		"data/extra.txt": "bag-not-in-manifest",
+		"data/gone.txt":  "bag-missing-file",
+		"bag-info.txt":   "bag-oxum-mismatch,bag-checksum-mismatch",
+		"../outside.txt": "bag-unsafe-path",
+		"/etc/passwd":    "bag-unsafe-path",
+	}
+	for path, codes := range expected {
+		if got[path] != codes {
+			t.Errorf("Expected %q to fail with %q, got %q", path, codes, got[path])
+		}
+	}
+	if len(got) != len(expected) {
+		t.Errorf("Expected failures on %d paths, got %#v", len(expected), got)
+	}
+}
+
+func TestBagCheckMissingTagFiles(t *testing.T) {
+	var root, cleanup = newTestTree(t, "bagcheck")
+	defer cleanup()
+	writeTestFile(t, root, "data/file.txt", "file")
+
+	var b = RegisterBagValidator(root, nil)
+	var got = make(map[string]string)
+	for _, p := range b.problems {
+		got[p.path] = p.err.Code
+	}
+	if got["bagit.txt"] != "bag-missing-tag-file" || got["manifest-*.txt"] != "bag-missing-tag-file" {
+		t.Errorf("Expected missing bagit.txt and manifest, got %#v", got)
+	}
+}
+
+func TestBagCheckSharesDigests(t *testing.T) {
+	var root, cleanup = newTestTree(t, "bagcheck")
+	defer cleanup()
+
+	writeTestFile(t, root, "bagit.txt", "BagIt-Version: 1.0\nTag-File-Character-Encoding: UTF-8\n")
+	writeTestFile(t, root, "data/one.txt", "same")
+	writeTestFile(t, root, "data/two.txt", "same")
+	writeTestFile(t, root, "manifest-md5.txt", md5hex("same")+"  data/one.txt\n"+md5hex("same")+"  data/two.txt\n")
+
+	var read []string
+	var c = recordingMD5(&read)
+	var store = NewSumStore(nil)
+	var b = RegisterBagValidator(root, store)
+	RegisterChecksumValidator(root, c, make(map[string][]string), nil, store)
+
+	var got = make(map[string]string)
+	var e = NewEngine()
+	e.TraverseFn = b.TraverseFn
+	e.ValidateTree(root, func(path string, fl []Failure) {
+		for _, f := range fl {
+			got[filepath.ToSlash(path)] = f.V.Name
+		}
+	})
+
+	// The bag validator already hashed each file with the same algorithm, so
+	// duplicate detection shouldn't need to read anything
+	if len(read) != 0 {
+		t.Errorf("Expected no further file reads, got %d", len(read))
+	}
+	if got["data/two.txt"] != "no-duped-content" {
+		t.Errorf("Expected data/two.txt to be reported as a duplicate, got %#v", got)
+	}
+}
+
+func TestBagCheckIgnoresCache(t *testing.T) {
+	var root, cleanup = newTestTree(t, "bagcheck")
+	defer cleanup()
+
+	writeTestFile(t, root, "bagit.txt", "BagIt-Version: 1.0\nTag-File-Character-Encoding: UTF-8\n")
+	writeTestFile(t, root, "data/file.txt", "changed")
+	writeTestFile(t, root, "manifest-md5.txt", md5hex("original")+"  data/file.txt\n")
+
+	// The cache claims the file still has its original content, as it would
+	// if the bytes changed without the size or modification time changing
+	var fullPath = filepath.Join(root, "data", "file.txt")
+	var info, _ = os.Stat(fullPath)
+	var cache = checksum.NewCache()
+	cache.Store(fullPath, info, map[string]string{"md5": md5hex("original")})
+
+	var b = RegisterBagValidator(root, NewSumStore(cache))
+	var got []string
+	var e = NewEngine()
+	e.TraverseFn = b.TraverseFn
+	e.ValidateTree(root, func(path string, fl []Failure) {
+		for _, f := range fl {
+			got = append(got, f.Code())
+		}
+	})
+
+	if len(got) != 1 || got[0] != "bag-checksum-mismatch" {
+		t.Errorf("Expected a checksum mismatch despite the stale cache, got %#v", got)
+	}
+}
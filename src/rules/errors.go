@@ -0,0 +1,54 @@
+package rules
+
+import (
+	"fmt"
+)
+
+// Params holds the named values substituted into a failure's message
+type Params map[string]interface{}
+
+// Error is a validation failure with a short, stable code identifying the
+// kind of problem, so reports can be compared across runs without depending
+// on the wording of the message.  The message itself comes from the message
+// catalog, keyed by the code, with Params filling in the details.
+type Error struct {
+	Code   string
+	Params Params
+
+	// expiredWaiver is set when a waiver would have covered this failure had
+	// it not expired, so the message can say so
+	expiredWaiver *Waiver
+}
+
+// newError returns an Error with the given code and message parameters
+func newError(code string, params Params) *Error {
+	return &Error{Code: code, Params: params}
+}
+
+// Error implements the error interface, rendering the message from the
+// current message catalog
+func (e *Error) Error() string {
+	var msg = message(e.Code, e.Params)
+	if e.expiredWaiver != nil {
+		msg = message("waiver-expired", Params{
+			"message":  msg,
+			"approver": e.expiredWaiver.ApprovedBy,
+			"expires":  e.expiredWaiver.Expires,
+		})
+	}
+	return msg
+}
+
+// quoted formats s the way messages show paths and other raw values
+func quoted(s string) string {
+	return fmt.Sprintf("%#v", s)
+}
+
+// Code returns the failure's code if the validator returned an *Error, or the
+// validator's name otherwise
+func (f Failure) Code() string {
+	if e, ok := f.E.(*Error); ok {
+		return e.Code
+	}
+	return f.V.Name
+}
@@ -1,7 +1,6 @@
 package rules
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
 )
@@ -18,7 +17,7 @@ func HasExtension(path string, info os.FileInfo) error {
 	}
 
 	if filepath.Ext(info.Name()) == "" {
-		return fmt.Errorf("doesn't have an extension")
+		return newError("no-extension", nil)
 	}
 
 	return nil
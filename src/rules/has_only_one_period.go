@@ -1,7 +1,6 @@
 package rules
 
 import (
-	"fmt"
 	"os"
 	"strings"
 )
@@ -15,7 +14,7 @@ func init() {
 func HasOnlyOnePeriod(path string, info os.FileInfo) error {
 	var c = strings.Count(info.Name(), ".")
 	if c > 1 {
-		return fmt.Errorf("has %d periods (maximum is 1)", c)
+		return newError("too-many-periods", Params{"count": c})
 	}
 
 	return nil
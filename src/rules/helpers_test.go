@@ -0,0 +1,65 @@
+package rules
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/uoregon-libraries/dark-archive-validator/src/checksum"
+)
+
+// newTestTree empties the validator list, so only the validators a test
+// registers will run, and creates a temporary directory for the test's files.
+// The returned function restores the validator list and removes the
+// directory.
+func newTestTree(t *testing.T, prefix string) (string, func()) {
+	var saved = validators
+	validators = nil
+
+	var root, err = ioutil.TempDir("", prefix)
+	if err != nil {
+		validators = saved
+		t.Fatalf("Unable to create temp dir: %s", err)
+	}
+	return root, func() {
+		validators = saved
+		os.RemoveAll(root)
+	}
+}
+
+func writeTestFile(t *testing.T, root, path, contents string) {
+	var fullPath = filepath.Join(root, filepath.FromSlash(path))
+	var err = os.MkdirAll(filepath.Dir(fullPath), 0755)
+	if err == nil {
+		err = ioutil.WriteFile(fullPath, []byte(contents), 0644)
+	}
+	if err != nil {
+		t.Fatalf("Unable to write %s: %s", path, err)
+	}
+}
+
+func md5hex(s string) string {
+	return fmt.Sprintf("%x", md5.Sum([]byte(s)))
+}
+
+// recordingMD5 returns an "md5" checksum which appends the full path of every
+// file it reads to read
+func recordingMD5(read *[]string) *checksum.Checksum {
+	var c = checksum.New(md5.New())
+	c.Name = "md5"
+	c.BlockWrite = func(path string, w io.Writer) error {
+		*read = append(*read, path)
+		var f, err = os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		_, err = io.Copy(w, f)
+		return err
+	}
+	return c
+}
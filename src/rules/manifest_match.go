@@ -0,0 +1,80 @@
+package rules
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/uoregon-libraries/dark-archive-validator/src/checksum"
+)
+
+// ManifestCheck tracks which of a manifest's files have been seen by the
+// manifest-match validator, so files missing from the tree can be reported
+// once the walk is done
+type ManifestCheck struct {
+	v        Validator
+	manifest map[string]string
+	seen     map[string]bool
+}
+
+// RegisterManifestValidator registers "manifest-match", which checksums every
+// regular file under root and compares it to manifest, a map of path
+// (relative to root, using forward slashes) to hex digest.  Files the
+// manifest doesn't list, and files whose checksum differs, are failures.
+// Since files which are missing entirely are never walked, the returned
+// ManifestCheck must be used to report them after the walk.
+func RegisterManifestValidator(root string, c *checksum.Checksum, manifest map[string]string) *ManifestCheck {
+	var m = &ManifestCheck{manifest: manifest, seen: make(map[string]bool)}
+	var validateManifest = func(path string, info os.FileInfo) error {
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		var key = filepath.ToSlash(path)
+		var expected, listed = manifest[key]
+		if !listed {
+			return newError("not-in-manifest", nil)
+		}
+		m.seen[key] = true
+
+		var sum, err = c.Sum(filepath.Join(root, path))
+		if err != nil && err != io.EOF {
+			return newError("checksum-failed", Params{"error": err})
+		}
+		var actual = fmt.Sprintf("%x", sum)
+		if actual != expected {
+			return newError("checksum-mismatch", Params{"expected": expected, "actual": actual})
+		}
+		return nil
+	}
+
+	m.v = Validator{Name: "manifest-match", vf: validateManifest, Criticality: CCritical, params: Params{"files": len(manifest)}}
+	if c.Name != "" {
+		m.v.params["algorithm"] = c.Name
+	}
+	register(m.v)
+	return m
+}
+
+// Missing returns the manifest's paths which haven't been seen, sorted
+func (m *ManifestCheck) Missing() []string {
+	var list []string
+	for path := range m.manifest {
+		if !m.seen[path] {
+			list = append(list, path)
+		}
+	}
+	sort.Strings(list)
+	return list
+}
+
+// ReportMissing sends a failure to the engine for each of the manifest's
+// paths which weren't seen
+func (m *ManifestCheck) ReportMissing(e *Engine, failFunc func(string, []Failure)) {
+	for _, path := range m.Missing() {
+		var fl = []Failure{{V: m.v, E: newError("missing-file", nil)}}
+		e.AddFailures(filepath.FromSlash(path), fl, failFunc)
+	}
+}
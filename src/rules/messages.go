@@ -0,0 +1,125 @@
+package rules
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// englishMessages is the default message catalog, keyed by failure code.
+// Each message may refer to its parameters by name, e.g., "{count}".
+var englishMessages = map[string]string{
+	"already-archived":        "has content already preserved in the dark archive at {locations}",
+	"bag-checksum-mismatch":   "has checksum {actual}, but {manifest} lists {expected}",
+	"bag-invalid-declaration": "must declare BagIt-Version and Tag-File-Character-Encoding",
+	"bag-missing-file":        "is listed in {manifest} but doesn't exist",
+	"bag-missing-tag-file":    "is required but doesn't exist",
+	"bag-not-in-manifest":     "isn't listed in {manifest}",
+	"bag-oxum-mismatch":       "has Payload-Oxum {expected}, but the payload is {actual}",
+	"bag-unknown-algorithm":   "uses an unsupported algorithm ({algorithm})",
+	"bag-unsafe-path":         "is listed in {manifest} but is outside the bag",
+	"checksum-failed":         "isn't able to be checksummed ({error})",
+	"checksum-mismatch":       "has checksum {actual}, but the manifest lists {expected}",
+	"control-chars":           "contains one or more control characters",
+	"device":                  "is a device file",
+	"dsc-invalid-chars":       "contains invalid characters: {chars}",
+	"duplicate-content":       "duplicates the content of {original}",
+	"duplicate-name":          "is a duplicate of {original}",
+	"empty-file":              "is an empty file",
+	"extraneous-file":         "may be an extraneous file; consider deletion",
+	"hidden":                  "is hidden (starts with a period)",
+	"invalid-unicode":         "contains invalid unicode",
+	"irregular-file":          "is not a regular file or folder",
+	"missing-file":            "is listed in the manifest but doesn't exist",
+	"named-pipe":              "is a named pipe",
+	"no-extension":            "doesn't have an extension",
+	"non-alpha-start":         "starts with a non-alphabetic character",
+	"not-in-manifest":         "isn't listed in the manifest",
+	"path-too-long":           "exceeds the maximum path length of {limit} characters",
+	"restricted-directory":    "doesn't match required directory pattern",
+	"restricted-filename":     "doesn't match required filename pattern",
+	"socket":                  "is a socket",
+	"space":                   "has a space in the filename",
+	"symlink":                 "is a symbolic link",
+	"too-many-periods":        "has {count} periods (maximum is 1)",
+	"trailing-space":          "ends with a space",
+	"unicode-chars":           "contains unicode characters ({chars})",
+	"unreadable":              "critical error: {error}",
+	"waiver-expired":          "{message} (waiver approved by {approver} expired {expires})",
+	"windows-invalid-chars":   "contains invalid characters: {chars}",
+	"windows-reserved-name":   "uses a reserved file name",
+	"windows-trailing-period": "has a trailing period",
+	"windows-trailing-space":  "has a trailing space",
+}
+
+// messages is the catalog currently in use.  Codes missing from it fall back
+// to the English catalog.
+var messages = englishMessages
+
+// message looks up the code's message and fills in its parameters.  Unknown
+// codes render as the code itself, with a "message" parameter if present, so
+// failures are never reported as empty strings.
+func message(code string, params Params) string {
+	var msg, ok = messages[code]
+	if !ok {
+		msg, ok = englishMessages[code]
+	}
+	if !ok {
+		if m, hasMessage := params["message"]; hasMessage {
+			return fmt.Sprint(m)
+		}
+		return code
+	}
+
+	// A single replacer pass means parameter values are never themselves
+	// searched for placeholders
+	var pairs []string
+	for name, val := range params {
+		pairs = append(pairs, "{"+name+"}", fmt.Sprint(val))
+	}
+	return strings.NewReplacer(pairs...).Replace(msg)
+}
+
+// LoadMessages reads a message catalog and uses it in place of the English
+// messages.  Each non-blank line which doesn't start with "#" must be a
+// failure code, an equals sign, and the message, e.g.:
+//
+//	too-many-periods = tiene {count} puntos (el máximo es 1)
+//
+// Codes the file doesn't define keep their English messages.
+func LoadMessages(filename string) error {
+	var f, err = os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var catalog = make(map[string]string)
+	var s = bufio.NewScanner(f)
+	var lineNum int
+	for s.Scan() {
+		lineNum++
+		var line = strings.TrimSpace(s.Text())
+		if line == "" || line[0] == '#' {
+			continue
+		}
+
+		var parts = strings.SplitN(line, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("%s line %d: expected \"code = message\"", filename, lineNum)
+		}
+		catalog[strings.TrimSpace(parts[0])] = strings.TrimSpace(parts[1])
+	}
+	if err = s.Err(); err != nil {
+		return err
+	}
+
+	messages = catalog
+	return nil
+}
+
+// ResetMessages goes back to using the default English messages
+func ResetMessages() {
+	messages = englishMessages
+}
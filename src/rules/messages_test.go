@@ -0,0 +1,18 @@
+package rules
+
+import (
+	"testing"
+)
+
+func TestMessageBracesInValues(t *testing.T) {
+	// Values which look like placeholders must come through untouched, no
+	// matter what order the params are visited in
+	var params = Params{"actual": "{expected}", "manifest": "{actual}", "expected": "abc"}
+	var expected = "has checksum {expected}, but {actual} lists abc"
+	for i := 0; i < 20; i++ {
+		var got = message("bag-checksum-mismatch", params)
+		if got != expected {
+			t.Fatalf("Expected %q, got %q", expected, got)
+		}
+	}
+}
@@ -1,10 +1,12 @@
 package rules
 
 import (
+	"crypto/sha256"
 	"fmt"
-	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 
 	"github.com/uoregon-libraries/dark-archive-validator/src/checksum"
 )
@@ -15,7 +17,15 @@ import (
 // checksums in the first place.  And since checksumming is optional, we don't
 // want to auto-register any particular checksum validator.  So this function
 // gets all that context, builds a validator function closure and registers it.
-func RegisterChecksumValidator(root string, c *checksum.Checksum, checksums map[string][]string) {
+//
+// checksums is keyed by c's main hash.  If c has Extra hashes and digests is
+// non-nil, digests is filled in with every extra digest, keyed by full path
+// and then algorithm name.
+//
+// If store is non-nil, and c's main hash is named, digests are taken from and
+// added to the store, so files aren't read again by other validators sharing
+// it, or at all if its cache already knows them.
+func RegisterChecksumValidator(root string, c *checksum.Checksum, checksums map[string][]string, digests map[string]map[string]string, store *SumStore) {
 	var validateChecksum = func(path string, info os.FileInfo) error {
 		// Don't try to checksum non-files
 		if !info.Mode().IsRegular() {
@@ -23,19 +33,155 @@ func RegisterChecksumValidator(root string, c *checksum.Checksum, checksums map[
 		}
 
 		var fullPath = filepath.Join(root, path)
-		var sum, err = c.Sum(fullPath)
-		if err != nil && err != io.EOF {
-			return fmt.Errorf("isn't able to be checksummed (%s)", err)
+		var chksum, extra, err = store.sum(c, fullPath, info)
+		if err != nil {
+			return newError("checksum-failed", Params{"error": err})
 		}
 
-		var chksum = fmt.Sprintf("%x", sum)
-		var chksumExist = checksums[chksum]
-		if len(chksumExist) != 0 {
-			err = fmt.Errorf("duplicates the content of %#v", checksums[chksum][0])
+		if digests != nil && len(extra) > 0 {
+			digests[fullPath] = extra
 		}
 
-		checksums[chksum] = append(checksums[chksum], fullPath)
-		return err
+		return recordChecksum(checksums, chksum, fullPath)
 	}
-	RegisterValidatorHigh("no-duped-content", validateChecksum)
+	var v = Validator{Name: "no-duped-content", vf: validateChecksum, Criticality: CHigh, params: Params{}}
+	if c.Name != "" {
+		v.params["algorithm"] = c.Name
+	}
+	if len(c.Extra) > 0 {
+		var names []string
+		for name := range c.Extra {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		v.params["extra_algorithms"] = strings.Join(names, ",")
+	}
+	register(v)
+}
+
+// recordChecksum adds fullPath to the list of files with the given checksum,
+// returning a duplicate-content error if another file already had it
+func recordChecksum(checksums map[string][]string, chksum, fullPath string) error {
+	var err error
+	var chksumExist = checksums[chksum]
+	if len(chksumExist) != 0 {
+		err = newError("duplicate-content", Params{"original": quoted(chksumExist[0])})
+	}
+
+	checksums[chksum] = append(checksums[chksum], fullPath)
+	return err
+}
+
+// partialBlockSize is how much of the start and end of a file is hashed when
+// looking for files which could be duplicates
+const partialBlockSize = 64 * 1024
+
+// RegisterSizeFirstChecksumValidator registers a "no-duped-content" validator
+// which finds the same duplicates as RegisterChecksumValidator while reading
+// far less data.  The first time it runs, it walks root with traverse to get
+// every file's size.  A file whose size is unique can't be a duplicate, so it
+// isn't read at all.  Files sharing a size have only their first and last
+// blocks hashed, and only files whose size and partial hash both match
+// another file's are fully checksummed.
+//
+// Since most files are never fully checksummed, checksums only gets the ones
+// which were, and this shouldn't be used when every file's checksum is needed.
+func RegisterSizeFirstChecksumValidator(root string, c *checksum.Checksum, checksums map[string][]string, store *SumStore, traverse func(string, filepath.WalkFunc) error) {
+	var idx = &sizeIndex{root: root, traverse: traverse}
+	var validateChecksum = func(path string, info os.FileInfo) error {
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		var fullPath = filepath.Join(root, path)
+		if !idx.candidate(fullPath, info.Size()) {
+			return nil
+		}
+
+		var chksum, _, err = store.sum(c, fullPath, info)
+		if err != nil {
+			return newError("checksum-failed", Params{"error": err})
+		}
+		return recordChecksum(checksums, chksum, fullPath)
+	}
+
+	var v = Validator{Name: "no-duped-content", vf: validateChecksum, Criticality: CHigh, params: Params{"strategy": "size-first"}}
+	if c.Name != "" {
+		v.params["algorithm"] = c.Name
+	}
+	register(v)
+}
+
+// sizeIndex groups a tree's files by size, and then by partial hash, so the
+// size-first strategy can tell which files might be duplicates
+type sizeIndex struct {
+	root     string
+	traverse func(string, filepath.WalkFunc) error
+	built    bool
+	sizes    map[int64][]string
+	partials map[string]string
+}
+
+// build walks the tree, recording the full path of every regular file by
+// size.  Walk errors are ignored: files the index doesn't know about are
+// always treated as possible duplicates.
+func (idx *sizeIndex) build() {
+	idx.built = true
+	idx.sizes = make(map[int64][]string)
+	idx.partials = make(map[string]string)
+	idx.traverse(idx.root, func(path string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			idx.sizes[info.Size()] = append(idx.sizes[info.Size()], path)
+		}
+		return nil
+	})
+}
+
+// candidate returns true if the file could have the same content as another
+// file in the tree
+func (idx *sizeIndex) candidate(fullPath string, size int64) bool {
+	if !idx.built {
+		idx.build()
+	}
+
+	var group = idx.sizes[size]
+	var found bool
+	for _, p := range group {
+		if p == fullPath {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return true
+	}
+	if len(group) < 2 {
+		return false
+	}
+
+	// Partial hashes are computed for the whole group at once, the first time
+	// any of its files is seen.  A file which can't be read gets a unique
+	// value so it's fully checksummed, and the failure is reported then.
+	if _, ok := idx.partials[fullPath]; !ok {
+		for _, p := range group {
+			var sum, err = checksum.PartialSum(sha256.New(), p, size, partialBlockSize)
+			if err != nil {
+				idx.partials[p] = "error:" + p
+				continue
+			}
+			idx.partials[p] = fmt.Sprintf("%x", sum)
+		}
+	}
+
+	var partial = idx.partials[fullPath]
+	if strings.HasPrefix(partial, "error:") {
+		return true
+	}
+	var matches int
+	for _, p := range group {
+		if idx.partials[p] == partial {
+			matches++
+		}
+	}
+	return matches > 1
 }
@@ -0,0 +1,56 @@
+package rules
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func TestSizeFirstChecksumValidator(t *testing.T) {
+	var root, cleanup = newTestTree(t, "sizefirst")
+	defer cleanup()
+
+	// Big enough that only the head and tail are hashed at first
+	var big = strings.Repeat("x", 3*partialBlockSize)
+	var middle = big[:partialBlockSize+1] + "y" + big[partialBlockSize+2:]
+	var tail = big[:len(big)-1] + "z"
+
+	writeTestFile(t, root, "unique.txt", "no other file is this size")
+	writeTestFile(t, root, "a/dupe.txt", "same")
+	writeTestFile(t, root, "b/dupe.txt", "same")
+	writeTestFile(t, root, "diff.txt", "diff")
+	writeTestFile(t, root, "big.bin", big)
+	writeTestFile(t, root, "middle.bin", middle)
+	writeTestFile(t, root, "tail.bin", tail)
+
+	var read []string
+	var c = recordingMD5(&read)
+
+	var sums = make(map[string][]string)
+	var e = NewEngine()
+	RegisterSizeFirstChecksumValidator(root, c, sums, nil, e.TraverseFn)
+	var got = make(map[string]string)
+	e.ValidateTree(root, func(path string, fl []Failure) {
+		for _, f := range fl {
+			got[filepath.ToSlash(path)] = f.Code()
+		}
+	})
+
+	// Walk order is lexical, so b/dupe.txt is the duplicate
+	if len(got) != 1 || got["b/dupe.txt"] != "duplicate-content" {
+		t.Errorf("Expected only b/dupe.txt to be a duplicate, got %#v", got)
+	}
+
+	// tail.bin and diff.txt share sizes with other files, but their partial
+	// hashes rule them out; unique.txt is never even opened
+	for i, path := range read {
+		var rel, _ = filepath.Rel(root, path)
+		read[i] = filepath.ToSlash(rel)
+	}
+	sort.Strings(read)
+	var expected = "a/dupe.txt,b/dupe.txt,big.bin,middle.bin"
+	if strings.Join(read, ",") != expected {
+		t.Errorf("Expected full reads of %s, got %s", expected, strings.Join(read, ","))
+	}
+}
@@ -1,7 +1,6 @@
 package rules
 
 import (
-	"fmt"
 	"os"
 )
 
@@ -15,7 +14,7 @@ func NoControlChars(path string, info os.FileInfo) error {
 	var name = info.Name()
 	for _, r := range name {
 		if r < 32 || r == 127 {
-			return fmt.Errorf("contains one or more control characters")
+			return newError("control-chars", nil)
 		}
 	}
 
@@ -1,7 +1,6 @@
 package rules
 
 import (
-	"fmt"
 	"os"
 	"strings"
 )
@@ -23,7 +22,7 @@ func ResetDupemap() {
 func NoDupedNames(path string, info os.FileInfo) error {
 	var pathUpper = strings.ToUpper(path)
 	if nameLookup[pathUpper] != "" {
-		return fmt.Errorf("is a duplicate of %#v", nameLookup[pathUpper])
+		return newError("duplicate-name", Params{"original": quoted(nameLookup[pathUpper])})
 	}
 
 	nameLookup[pathUpper] = path
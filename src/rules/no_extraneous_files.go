@@ -1,7 +1,6 @@
 package rules
 
 import (
-	"fmt"
 	"os"
 )
 
@@ -13,7 +12,7 @@ func init() {
 // unnecessary file types aren't included, such as Thumbs.db, .DS_Store, etc.
 func NoExtraneousFiles(path string, info os.FileInfo) error {
 	var n = info.Name()
-	var genericError = fmt.Errorf("may be an extraneous file; consider deletion")
+	var genericError = newError("extraneous-file", nil)
 
 	if n == ".DS_Store" || n == "Thumbs.db" || n == "desktop.ini" {
 		return genericError
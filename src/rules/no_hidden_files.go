@@ -1,7 +1,6 @@
 package rules
 
 import (
-	"fmt"
 	"os"
 )
 
@@ -13,7 +12,7 @@ func init() {
 // read attrs for Windows files, too
 func NoHiddenFiles(path string, info os.FileInfo) error {
 	if info.Name()[0] == '.' {
-		return fmt.Errorf("is hidden (starts with a period)")
+		return newError("hidden", nil)
 	}
 
 	return nil
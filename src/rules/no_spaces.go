@@ -1,7 +1,6 @@
 package rules
 
 import (
-	"fmt"
 	"os"
 	"unicode"
 )
@@ -26,11 +25,11 @@ func NoSpaces(path string, info os.FileInfo) error {
 	}
 
 	if spaceAtEnd {
-		return fmt.Errorf("ends with a space")
+		return newError("trailing-space", nil)
 	}
 
 	if hasSpace {
-		return fmt.Errorf("has a space in the filename")
+		return newError("space", nil)
 	}
 
 	return nil
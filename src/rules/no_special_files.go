@@ -1,7 +1,6 @@
 package rules
 
 import (
-	"fmt"
 	"os"
 )
 
@@ -23,20 +22,20 @@ func NoSpecialFiles(path string, info os.FileInfo) error {
 	}
 
 	if m&os.ModeSymlink != 0 {
-		return fmt.Errorf("is a symbolic link")
+		return newError("symlink", nil)
 	}
 
 	if m&os.ModeDevice != 0 {
-		return fmt.Errorf("is a device file")
+		return newError("device", nil)
 	}
 
 	if m&os.ModeNamedPipe != 0 {
-		return fmt.Errorf("is a named pipe")
+		return newError("named-pipe", nil)
 	}
 
 	if m&os.ModeSocket != 0 {
-		return fmt.Errorf("is a socket")
+		return newError("socket", nil)
 	}
 
-	return fmt.Errorf("is not a regular file or folder")
+	return newError("irregular-file", nil)
 }
@@ -1,7 +1,6 @@
 package rules
 
 import (
-	"fmt"
 	"os"
 )
 
@@ -12,7 +11,7 @@ func init() {
 // NonzeroFilesize enforces that all regular files are at least 1 byte
 func NonzeroFilesize(path string, info os.FileInfo) error {
 	if info.Size() == 0 && info.Mode().IsRegular() {
-		return fmt.Errorf("is an empty file")
+		return newError("empty-file", nil)
 	}
 
 	return nil
@@ -1,12 +1,12 @@
 package rules
 
 import (
-	"fmt"
 	"os"
 )
 
 func init() {
-	RegisterValidatorHigh("path-limit", PathLimitFn(200))
+	var limit = 200
+	register(Validator{Name: "path-limit", vf: PathLimitFn(limit), Criticality: CHigh, params: Params{"limit": limit}})
 }
 
 // PathLimitFn returns a validator function which will report when the path
@@ -14,7 +14,7 @@ func init() {
 func PathLimitFn(n int) ValidatorFunc {
 	return func(path string, info os.FileInfo) error {
 		if len(path) > n {
-			return fmt.Errorf("exceeds the maximum path length of %d characters", n)
+			return newError("path-too-long", Params{"limit": n})
 		}
 		return nil
 	}
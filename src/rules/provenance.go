@@ -0,0 +1,85 @@
+package rules
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/user"
+	"path/filepath"
+	"time"
+)
+
+// Provenance records who ran a validation, where, when, and with exactly
+// which rules, so a report can be tied back to the run that produced it
+type Provenance struct {
+	Tool        string          `json:"tool"`
+	Host        string          `json:"host"`
+	User        string          `json:"user"`
+	Root        string          `json:"root"`
+	Time        time.Time       `json:"time"`
+	Validators  []ValidatorInfo `json:"validators"`
+	RuleSetHash string          `json:"rule_set_hash"`
+}
+
+// ValidatorInfo describes one validator in a run's effective rule set
+type ValidatorInfo struct {
+	Name                   string      `json:"name"`
+	Criticality            Criticality `json:"criticality"`
+	Priority               int8        `json:"priority"`
+	SkipOnPreviousFailures bool        `json:"skip_on_previous_failures"`
+	StopOnFailure          bool        `json:"stop_on_failure"`
+	Params                 Params      `json:"params,omitempty"`
+}
+
+// Info returns the validator's description for provenance
+func (v Validator) Info() ValidatorInfo {
+	return ValidatorInfo{
+		Name:                   v.Name,
+		Criticality:            v.Criticality,
+		Priority:               v.priority,
+		SkipOnPreviousFailures: v.skipOnPreviousFailures,
+		StopOnFailure:          v.stopOnFailure,
+		Params:                 v.params,
+	}
+}
+
+// newProvenance gathers provenance for a run of the engine's validators
+// against root.  Host and user are best-effort: if they can't be determined
+// they're left blank rather than failing the run.
+func (e *Engine) newProvenance(root string) *Provenance {
+	var p = &Provenance{Tool: e.Tool, Root: root, Time: time.Now()}
+	var abs, err = filepath.Abs(root)
+	if err == nil {
+		p.Root = abs
+	}
+
+	p.Host, _ = os.Hostname()
+	var u *user.User
+	u, err = user.Current()
+	if err == nil {
+		p.User = u.Username
+	} else {
+		p.User = os.Getenv("USER")
+	}
+
+	for _, v := range e.Validators() {
+		p.Validators = append(p.Validators, v.Info())
+	}
+	p.RuleSetHash = ruleSetHash(p.Validators)
+
+	return p
+}
+
+// ruleSetHash returns a SHA-256 digest identifying a list of validators and
+// their settings.  Two runs with the same hash applied the same rules, in the
+// same order, with the same parameters.
+func ruleSetHash(list []ValidatorInfo) string {
+	var h = sha256.New()
+	var enc = json.NewEncoder(h)
+	for _, vi := range list {
+		// Encoding maps sorts their keys, so this is deterministic
+		enc.Encode(vi)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
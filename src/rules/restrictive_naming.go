@@ -1,7 +1,6 @@
 package rules
 
 import (
-	"fmt"
 	"os"
 	"regexp"
 )
@@ -37,5 +36,5 @@ func RestrictiveNaming(path string, info os.FileInfo) error {
 		return nil
 	}
 
-	return fmt.Errorf("doesn't match required %s pattern", errType)
+	return newError("restricted-"+errType, nil)
 }
@@ -1,11 +1,11 @@
 package rules
 
 import (
-	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"time"
 )
 
 // Failure keeps a validator and the error returned in one place for easy
@@ -29,9 +29,35 @@ func init() {
 }
 
 // Engine is the rules runner.  By default it will run all known validators
-// except those explicitly skipped.
+// except those explicitly skipped.  Stats, Waived, and Provenance are reset
+// each time ValidateTree is called, and describe the most recent run.
+//
+// Waivers, if set, are consulted before any failures are reported: failures
+// covered by a current waiver are recorded in Waived instead of being
+// reported.  FilterFn, if set, is then given each path's remaining failures
+// and returns the failures which should still be reported.  This allows
+// callers to suppress findings which have already been accepted.
+//
+// EntryFn, if set, is called for every path examined, whether or not it
+// failed, with whatever failures were reported.  For paths which couldn't be
+// read at all, info may be nil.
+//
+// RanFn, if set, is called each time a validator actually runs against a
+// path, which isn't the case for skipped validators, or for those prevented
+// from running by earlier failures.
+//
+// Tool names the program running the engine (e.g., its name and version), and
+// is recorded in Provenance along with the rest of the run's details.
 type Engine struct {
 	TraverseFn func(string, filepath.WalkFunc) error
+	FilterFn   func(string, []Failure) []Failure
+	EntryFn    func(string, os.FileInfo, []Failure)
+	RanFn      func(path, validator string)
+	Waivers    *WaiverList
+	Tool       string
+	Provenance *Provenance
+	Stats      *Stats
+	Waived     []WaivedFailure
 	skip       map[string]bool
 }
 
@@ -39,6 +65,7 @@ type Engine struct {
 func NewEngine() *Engine {
 	return &Engine{
 		TraverseFn: filepath.Walk,
+		Stats:      newStats(),
 		skip:       make(map[string]bool),
 	}
 }
@@ -83,6 +110,12 @@ func (e *Engine) SkipAll() {
 // registered validators, yielding to failFunc whenever a validation against a
 // file returns any errors
 func (e *Engine) ValidateTree(root string, failFunc func(string, []Failure)) {
+	e.Stats = newStats()
+	e.Waived = nil
+	e.Provenance = e.newProvenance(root)
+	e.Stats.Start = time.Now()
+	defer func() { e.Stats.End = time.Now() }()
+
 	e.TraverseFn(root, func(path string, info os.FileInfo, err error) error {
 		var basepath = strings.Replace(path, root, "", 1)
 		if len(basepath) > 0 && basepath[0] == filepath.Separator {
@@ -91,8 +124,8 @@ func (e *Engine) ValidateTree(root string, failFunc func(string, []Failure)) {
 
 		if err != nil {
 			var fl = make([]Failure, 1)
-			fl[0] = Failure{V: badFileValidator, E: fmt.Errorf("critical error: %s", err)}
-			failFunc(basepath, fl)
+			fl[0] = Failure{V: badFileValidator, E: newError("unreadable", Params{"error": err})}
+			e.entry(basepath, info, e.report(basepath, fl, failFunc))
 			return nil
 		}
 
@@ -102,15 +135,52 @@ func (e *Engine) ValidateTree(root string, failFunc func(string, []Failure)) {
 			return nil
 		}
 
-		var fl = e.Validate(basepath, info)
-		if len(fl) > 0 {
-			failFunc(basepath, fl)
-		}
+		e.Stats.addEntry(info)
+		e.entry(basepath, info, e.report(basepath, e.Validate(basepath, info), failFunc))
 
 		return nil
 	})
 }
 
+// AddFailures reports failures for a path the walk didn't visit, such as a
+// file which should exist but doesn't.  The failures are treated exactly as
+// though a validator had found them: waivers and FilterFn apply, the stats
+// count them, and EntryFn sees the path (with a nil FileInfo).
+func (e *Engine) AddFailures(basepath string, fl []Failure, failFunc func(string, []Failure)) {
+	e.entry(basepath, nil, e.report(basepath, fl, failFunc))
+}
+
+// report runs a path's failures through the waivers and FilterFn, counts
+// whatever is left, and sends it to failFunc.  The reported failures are
+// returned.
+func (e *Engine) report(basepath string, fl []Failure, failFunc func(string, []Failure)) []Failure {
+	if len(fl) == 0 {
+		return nil
+	}
+
+	var waived []WaivedFailure
+	fl, waived = e.Waivers.apply(basepath, fl)
+	e.Waived = append(e.Waived, waived...)
+	e.Stats.Waived += len(waived)
+
+	if e.FilterFn != nil && len(fl) > 0 {
+		fl = e.FilterFn(basepath, fl)
+	}
+
+	e.Stats.addFailures(fl)
+	if len(fl) > 0 {
+		failFunc(basepath, fl)
+	}
+	return fl
+}
+
+// entry sends an examined path to EntryFn if there is one
+func (e *Engine) entry(basepath string, info os.FileInfo, fl []Failure) {
+	if e.EntryFn != nil {
+		e.EntryFn(basepath, info, fl)
+	}
+}
+
 // Validators returns a sorted list of all validators which are not explicitly
 // skipped - though the Windows filename restrictions are forcibly added to the
 // list no matter what.  We sort by priority and then name in order to allow
@@ -131,13 +201,22 @@ func (e *Engine) Validators() ValidatorList {
 }
 
 // Validate checks the given base path against all validators not in the skip
-// list, and returns an array of errors found
+// list, and returns an array of errors found.  The time spent in each
+// validator is added to the engine's stats.
 func (e *Engine) Validate(basepath string, info os.FileInfo) []Failure {
 	var flist []Failure
 
 	var v Validator
 	for _, v = range e.Validators() {
+		if !v.shouldRun(flist) {
+			continue
+		}
+		var start = time.Now()
 		flist = v.Validate(basepath, info, flist)
+		e.Stats.addTiming(v.Name, time.Since(start))
+		if e.RanFn != nil {
+			e.RanFn(basepath, v.Name)
+		}
 	}
 
 	return flist
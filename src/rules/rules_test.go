@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
@@ -198,6 +199,213 @@ func ExampleEngine_noSkippingCriticalValidations() {
 	// After SkipAll, found valid-windows-filename
 }
 
+// fakeFileWalkStats walks a small tree with a mix of good and bad entries so
+// the run statistics have something to count
+func fakeFileWalkStats(root string, walkfn filepath.WalkFunc) error {
+	var walk = func(dir string, i os.FileInfo) {
+		var fullPath = filepath.Join(root, dir, i.Name())
+		walkfn(fullPath, i, nil)
+	}
+
+	walk("", rules.NewFakeDir("stuff"))
+	walk("stuff", rules.NewFakeFile("goodfile.txt", 1000))
+	walk("stuff", rules.NewFakeFile("bad file.txt", 24))
+	walk("stuff", rules.NewFakeFile("GOODFILE.txt", 100))
+	walk("", rules.NewFakeSymlink("flarb"))
+
+	return nil
+}
+
+// This example shows the statistics gathered during a run
+func ExampleEngine_stats() {
+	rules.ResetDupemap()
+	var e = rules.NewEngine()
+	e.TraverseFn = fakeFileWalkStats
+	e.ValidateTree("/blah", func(string, []rules.Failure) {})
+
+	var s = e.Stats
+	fmt.Printf("%d files, %d dirs, %d bytes\n", s.Files, s.Directories, s.Bytes)
+	fmt.Printf("%d paths had %d failures\n", s.FailedPaths, s.Failures())
+	fmt.Printf("no-duped-names: %d, no-spaces: %d\n", s.ValidatorFailures["no-duped-names"], s.ValidatorFailures["no-spaces"])
+	fmt.Printf("Critical: %d, High: %d, Normal: %d\n", s.CriticalityFailures[rules.CCritical],
+		s.CriticalityFailures[rules.CHigh], s.CriticalityFailures[rules.CNormal])
+
+	// restrictive-naming only runs on paths which haven't already failed
+	fmt.Printf("Calls: no-spaces %d, restrictive-naming %d\n", s.Timings["no-spaces"].Calls, s.Timings["restrictive-naming"].Calls)
+
+	// Output:
+	// 4 files, 1 dirs, 1124 bytes
+	// 3 paths had 3 failures
+	// no-duped-names: 1, no-spaces: 1
+	// Critical: 1, High: 1, Normal: 1
+	// Calls: no-spaces 5, restrictive-naming 2
+}
+
+// This example suppresses a finding with a filter function, the way a
+// baseline of accepted failures would
+func ExampleEngine_filterFn() {
+	rules.ResetDupemap()
+	var e = rules.NewEngine()
+	e.TraverseFn = fakeFileWalkStats
+	e.FilterFn = func(path string, fl []rules.Failure) []rules.Failure {
+		var kept []rules.Failure
+		for _, f := range fl {
+			if path == "flarb" && f.Code() == "symlink" {
+				continue
+			}
+			kept = append(kept, f)
+		}
+		return kept
+	}
+	e.ValidateTree("/blah", func(path string, fl []rules.Failure) {
+		for _, f := range fl {
+			fmt.Printf("%s (%s) says %#v %s\n", f.V.Name, f.Code(), path, f.E)
+		}
+	})
+	fmt.Printf("%d paths had %d failures\n", e.Stats.FailedPaths, e.Stats.Failures())
+
+	// Output:
+	// no-spaces (space) says "stuff/bad file.txt" has a space in the filename
+	// no-duped-names (duplicate-name) says "stuff/GOODFILE.txt" is a duplicate of "stuff/goodfile.txt"
+	// 2 paths had 2 failures
+}
+
+// This example shows which paths a validator actually ran against.
+// restrictive-naming skips paths which have already failed.
+func ExampleEngine_ranFn() {
+	rules.ResetDupemap()
+	var e = rules.NewEngine()
+	e.TraverseFn = fakeFileWalkStats
+	e.RanFn = func(path, validator string) {
+		if validator == "restrictive-naming" {
+			fmt.Printf("restrictive-naming ran on %#v\n", path)
+		}
+	}
+	e.ValidateTree("/blah", func(string, []rules.Failure) {})
+
+	// Output:
+	// restrictive-naming ran on "stuff"
+	// restrictive-naming ran on "stuff/goodfile.txt"
+}
+
+// This example waives one failure and shows an expired waiver resurfacing
+// its failure
+func ExampleEngine_waivers() {
+	rules.ResetDupemap()
+	var e = rules.NewEngine()
+	e.TraverseFn = fakeFileWalkStats
+
+	var wl, err = rules.NewWaiverList([]rules.Waiver{
+		{
+			Path:          "stuff/bad file.txt",
+			Validator:     "no-spaces",
+			ApprovedBy:    "archivist",
+			Justification: "Name is evidence of the donor's workflow",
+			Expires:       "2999-12-31",
+		},
+		{
+			Path:          "flarb",
+			Validator:     "no-special-files",
+			Code:          "symlink",
+			ApprovedBy:    "archivist",
+			Justification: "Temporary; donor will resend",
+			Expires:       "2001-01-01",
+		},
+	})
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	e.Waivers = wl
+
+	e.ValidateTree("/blah", failFunc)
+	for _, w := range e.Waived {
+		fmt.Printf("waived %s on %#v, approved by %s\n", w.Failure.V.Name, w.Path, w.Waiver.ApprovedBy)
+	}
+
+	// Output:
+	// no-duped-names says "stuff/GOODFILE.txt" is a duplicate of "stuff/goodfile.txt"
+	// no-special-files says "flarb" is a symbolic link (waiver approved by archivist expired 2001-01-01)
+	// waived no-spaces on "stuff/bad file.txt", approved by archivist
+}
+
+// This example loads a message catalog to report failures in Spanish.  Codes
+// missing from the catalog fall back to English.
+func ExampleLoadMessages() {
+	var f, err = ioutil.TempFile("", "messages")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.Remove(f.Name())
+	f.WriteString("# Spanish\nspace = tiene un espacio en el nombre del archivo\n")
+	f.Close()
+
+	err = rules.LoadMessages(f.Name())
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer rules.ResetMessages()
+
+	rules.ResetDupemap()
+	var e = rules.NewEngine()
+	e.TraverseFn = fakeFileWalkStats
+	e.ValidateTree("/blah", failFunc)
+
+	// Output:
+	// no-spaces says "stuff/bad file.txt" tiene un espacio en el nombre del archivo
+	// no-duped-names says "stuff/GOODFILE.txt" is a duplicate of "stuff/goodfile.txt"
+	// no-special-files says "flarb" is a symbolic link
+}
+
+// This example lists every path examined, passing or failing, the way an
+// inventory would
+func ExampleEngine_entryFn() {
+	rules.ResetDupemap()
+	var e = rules.NewEngine()
+	e.TraverseFn = fakeFileWalkStats
+	e.EntryFn = func(path string, info os.FileInfo, fl []rules.Failure) {
+		fmt.Printf("%#v: %d bytes, %d failures\n", path, info.Size(), len(fl))
+	}
+	e.ValidateTree("/blah", func(string, []rules.Failure) {})
+
+	// Output:
+	// "stuff": 0 bytes, 0 failures
+	// "stuff/goodfile.txt": 1000 bytes, 0 failures
+	// "stuff/bad file.txt": 24 bytes, 1 failures
+	// "stuff/GOODFILE.txt": 100 bytes, 1 failures
+	// "flarb": 0 bytes, 1 failures
+}
+
+// This example shows the provenance recorded for a run.  The rule set hash
+// identifies exactly which validators ran, so skipping one changes it.
+func ExampleEngine_provenance() {
+	rules.ResetDupemap()
+	var e = rules.NewEngine()
+	e.Tool = "example 1.0"
+	e.TraverseFn = fakeFileWalkStats
+	e.ValidateTree("/blah", func(string, []rules.Failure) {})
+	var p = e.Provenance
+
+	fmt.Printf("%s validated %s\n", p.Tool, p.Root)
+	for _, vi := range p.Validators {
+		if vi.Name == "path-limit" {
+			fmt.Printf("%s (%s): limit %v\n", vi.Name, vi.Criticality, vi.Params["limit"])
+			break
+		}
+	}
+
+	e.Skip("no-spaces")
+	e.ValidateTree("/blah", func(string, []rules.Failure) {})
+	fmt.Printf("Skipping a validator changes the hash: %v\n", e.Provenance.RuleSetHash != p.RuleSetHash)
+
+	// Output:
+	// example 1.0 validated /blah
+	// path-limit (High): limit 200
+	// Skipping a validator changes the hash: true
+}
+
 func fakeBlockWrite(path string, w io.Writer) error {
 	var basename = filepath.Base(path)
 	w.Write([]byte(basename))
@@ -210,9 +418,35 @@ func ExampleEngine_onlyTestChecksums() {
 	e.TraverseFn = fakeFileWalkChecksum
 	e.SkipAll()
 	var chksum = make(map[string][]string)
-	rules.RegisterChecksumValidator("/blah", &checksum.Checksum{sha256.New(), fakeBlockWrite}, chksum)
+	var c = &checksum.Checksum{Hash: sha256.New(), BlockWrite: fakeBlockWrite}
+	c.AddAlgorithm("md5")
+	var digests = make(map[string]map[string]string)
+	rules.RegisterChecksumValidator("/blah", c, chksum, digests, nil)
 	e.ValidateTree("/blah", failFunc)
+	fmt.Printf("b/two.txt md5: %s\n", digests["/blah/b/two.txt"]["md5"])
 
 	// Output:
 	// no-duped-content says "b/one.txt" duplicates the content of "/blah/a/one.txt"
+	// b/two.txt md5: 4d38067eaaa3039b8a9a2307a654744c
+}
+
+// This example compares a tree against a manifest.  Since it replaces the
+// validator list, it must remain the last example.
+func ExampleRegisterManifestValidator() {
+	rules.NukeValidatorList()
+	var e = rules.NewEngine()
+	e.TraverseFn = fakeFileWalkChecksum
+	var c = &checksum.Checksum{Hash: sha256.New(), BlockWrite: fakeBlockWrite}
+	var m = rules.RegisterManifestValidator("/blah", c, map[string]string{
+		"a/one.txt":   fmt.Sprintf("%x", sha256.Sum256([]byte("one.txt"))),
+		"b/one.txt":   "abc123",
+		"c/three.txt": "def456",
+	})
+	e.ValidateTree("/blah", failFunc)
+	m.ReportMissing(e, failFunc)
+
+	// Output:
+	// manifest-match says "b/one.txt" has checksum 4bc812ba0c30fc415977fb34715b63843b3e1f324aa869e40eb26d08fa2ca900, but the manifest lists abc123
+	// manifest-match says "b/two.txt" isn't listed in the manifest
+	// manifest-match says "c/three.txt" is listed in the manifest but doesn't exist
 }
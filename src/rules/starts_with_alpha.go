@@ -1,7 +1,6 @@
 package rules
 
 import (
-	"fmt"
 	"os"
 )
 
@@ -19,5 +18,5 @@ func StartsWithAlpha(path string, info os.FileInfo) error {
 		return nil
 	}
 
-	return fmt.Errorf("starts with a non-alphabetic character")
+	return newError("non-alpha-start", nil)
 }
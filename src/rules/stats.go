@@ -0,0 +1,106 @@
+package rules
+
+import (
+	"os"
+	"time"
+)
+
+// Stats holds the counts gathered while an Engine walks a tree, for reporting
+// a summary of what was examined and what failed
+type Stats struct {
+	Files       int
+	Directories int
+	Bytes       int64
+
+	// RegularFiles is the part of Files which are regular files, rather than
+	// symlinks, devices, and the like
+	RegularFiles int
+
+	// FailedPaths is the number of paths which had at least one failure
+	FailedPaths int
+
+	// Waived is the number of failures which a waiver kept from being reported
+	Waived int
+
+	// ValidatorFailures and CriticalityFailures count individual failures, so
+	// a single path can add to more than one validator's count
+	ValidatorFailures   map[string]int
+	CriticalityFailures map[Criticality]int
+
+	// Timings holds the number of calls to, and total time spent in, each
+	// validator
+	Timings map[string]*Timing
+
+	Start time.Time
+	End   time.Time
+}
+
+// Timing is the cumulative cost of a single validator
+type Timing struct {
+	Calls    int
+	Duration time.Duration
+}
+
+func newStats() *Stats {
+	return &Stats{
+		ValidatorFailures:   make(map[string]int),
+		CriticalityFailures: make(map[Criticality]int),
+		Timings:             make(map[string]*Timing),
+	}
+}
+
+// Duration returns how long the run took, or how long it has been running if
+// it hasn't finished yet
+func (s *Stats) Duration() time.Duration {
+	if s.End.IsZero() {
+		return time.Since(s.Start)
+	}
+	return s.End.Sub(s.Start)
+}
+
+// Failures returns the total number of individual failures seen
+func (s *Stats) Failures() int {
+	var n int
+	for _, count := range s.ValidatorFailures {
+		n += count
+	}
+	return n
+}
+
+// addEntry counts a file or directory which was examined
+func (s *Stats) addEntry(info os.FileInfo) {
+	if info.IsDir() {
+		s.Directories++
+		return
+	}
+
+	s.Files++
+	if info.Mode().IsRegular() {
+		s.RegularFiles++
+		s.Bytes += info.Size()
+	}
+}
+
+// addFailures counts the failures found on a single path
+func (s *Stats) addFailures(fl []Failure) {
+	if len(fl) == 0 {
+		return
+	}
+
+	s.FailedPaths++
+	for _, f := range fl {
+		s.ValidatorFailures[f.V.Name]++
+		s.CriticalityFailures[f.V.Criticality]++
+	}
+}
+
+// addTiming records a single call to the named validator
+func (s *Stats) addTiming(name string, d time.Duration) {
+	var t = s.Timings[name]
+	if t == nil {
+		t = &Timing{}
+		s.Timings[name] = t
+	}
+	t.Calls++
+	t.Duration += d
+}
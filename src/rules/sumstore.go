@@ -0,0 +1,185 @@
+package rules
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/uoregon-libraries/dark-archive-validator/src/checksum"
+)
+
+// SumStore holds every named digest computed during a run, keyed by full path
+// and then algorithm, so validators sharing a store never read a file twice
+// for the same algorithm.  If it has a Cache, digests from earlier runs are
+// used as well, and new digests are added to the cache.  A nil store does no
+// caching at all.
+type SumStore struct {
+	Cache *checksum.Cache
+	sums  map[string]map[string]string
+}
+
+// NewSumStore returns an empty store using the given cache, which may be nil
+func NewSumStore(cache *checksum.Cache) *SumStore {
+	return &SumStore{Cache: cache, sums: make(map[string]map[string]string)}
+}
+
+// lookup returns the file's stored digests for every one of algs, if they're
+// all known this run or in the cache
+func (s *SumStore) lookup(fullPath string, info os.FileInfo, algs []string) (map[string]string, bool) {
+	var known = s.sums[fullPath]
+	var found = make(map[string]string)
+	for _, alg := range algs {
+		var sum, ok = known[alg]
+		if !ok {
+			found = nil
+			break
+		}
+		found[alg] = sum
+	}
+	if found != nil {
+		return found, true
+	}
+
+	if s.Cache == nil {
+		return nil, false
+	}
+	found, ok := s.Cache.Lookup(fullPath, info, algs)
+	if ok {
+		s.add(fullPath, found)
+	}
+	return found, ok
+}
+
+// add records digests computed for the file
+func (s *SumStore) add(fullPath string, sums map[string]string) {
+	if s.sums[fullPath] == nil {
+		s.sums[fullPath] = make(map[string]string)
+	}
+	for alg, sum := range sums {
+		s.sums[fullPath][alg] = sum
+	}
+}
+
+// sum returns the hex digest of the file's main hash and a map of its extra
+// hashes' digests, using the store if possible.  Checksums without a name
+// can't be stored, so they always read the file.
+func (s *SumStore) sum(c *checksum.Checksum, fullPath string, info os.FileInfo) (string, map[string]string, error) {
+	if c.Name == "" {
+		s = nil
+	}
+
+	if s != nil {
+		var algs = []string{c.Name}
+		for name := range c.Extra {
+			algs = append(algs, name)
+		}
+		var sums, ok = s.lookup(fullPath, info, algs)
+		if ok {
+			return sums[c.Name], extraSums(c, sums), nil
+		}
+	}
+
+	return s.read(c, fullPath, info)
+}
+
+// read always reads the file, returning the same values as sum, and adds the
+// new digests to the store and its cache
+func (s *SumStore) read(c *checksum.Checksum, fullPath string, info os.FileInfo) (string, map[string]string, error) {
+	if c.Name == "" {
+		s = nil
+	}
+
+	var sum, extra, err = c.SumAll(fullPath)
+	if err != nil && err != io.EOF {
+		return "", nil, err
+	}
+
+	var sums = map[string]string{c.Name: fmt.Sprintf("%x", sum)}
+	for name, x := range extra {
+		sums[name] = fmt.Sprintf("%x", x)
+	}
+	if s != nil {
+		s.add(fullPath, sums)
+		if s.Cache != nil {
+			s.Cache.Store(fullPath, info, sums)
+		}
+	}
+	return sums[c.Name], extraSums(c, sums), nil
+}
+
+// sumAlgorithms returns the file's digests for each of algs, reading the file
+// once for whichever aren't already in the store
+func (s *SumStore) sumAlgorithms(fullPath string, info os.FileInfo, algs []string) (map[string]string, error) {
+	var sums = make(map[string]string)
+	var c *checksum.Checksum
+	for _, alg := range algs {
+		if s != nil {
+			if sum, ok := s.sums[fullPath][alg]; ok {
+				sums[alg] = sum
+				continue
+			}
+		}
+		var err error
+		if c == nil {
+			c, err = checksum.NewAlgorithm(alg)
+		} else {
+			err = c.AddAlgorithm(alg)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if c == nil {
+		return sums, nil
+	}
+
+	var sum, extra, err = s.sum(c, fullPath, info)
+	if err != nil {
+		return nil, err
+	}
+	sums[c.Name] = sum
+	for name, x := range extra {
+		sums[name] = x
+	}
+	return sums, nil
+}
+
+// verifyAlgorithms returns the file's digests for each of algs, always
+// reading the file rather than trusting the store or its cache, for checks
+// such as fixity which must see the file's actual bytes.  The digests are
+// still added to the store, so other validators needn't read the file again.
+func (s *SumStore) verifyAlgorithms(fullPath string, info os.FileInfo, algs []string) (map[string]string, error) {
+	var c, err = checksum.NewAlgorithm(algs[0])
+	for _, alg := range algs[1:] {
+		if err == nil {
+			err = c.AddAlgorithm(alg)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var sum string
+	var extra map[string]string
+	sum, extra, err = s.read(c, fullPath, info)
+	if err != nil {
+		return nil, err
+	}
+	var sums = map[string]string{c.Name: sum}
+	for name, x := range extra {
+		sums[name] = x
+	}
+	return sums, nil
+}
+
+// extraSums pulls the digests of c's extra hashes out of sums
+func extraSums(c *checksum.Checksum, sums map[string]string) map[string]string {
+	if len(c.Extra) == 0 {
+		return nil
+	}
+	var extra = make(map[string]string)
+	for name := range c.Extra {
+		extra[name] = sums[name]
+	}
+	return extra
+}
@@ -0,0 +1,29 @@
+package rules
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+)
+
+func TestSumStoreUnknownAlgorithm(t *testing.T) {
+	var f, err = ioutil.TempFile("", "sumstore")
+	if err != nil {
+		t.Fatalf("Unable to create temp file: %s", err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+	var info, _ = os.Stat(f.Name())
+
+	var store = NewSumStore(nil)
+	for _, algs := range [][]string{{"crc99"}, {"md5", "crc99"}} {
+		_, err = store.sumAlgorithms(f.Name(), info, algs)
+		if err == nil {
+			t.Errorf("Expected sumAlgorithms to fail for %v", algs)
+		}
+		_, err = store.verifyAlgorithms(f.Name(), info, algs)
+		if err == nil {
+			t.Errorf("Expected verifyAlgorithms to fail for %v", algs)
+		}
+	}
+}
@@ -43,7 +43,7 @@ func NoUTF8(path string, info os.FileInfo) error {
 	}
 
 	if len(utfRunes) > 0 {
-		return fmt.Errorf("contains unicode characters (%s)", runeListErrorString(utfRunes))
+		return newError("unicode-chars", Params{"chars": runeListErrorString(utfRunes)})
 	}
 
 	return nil
@@ -55,7 +55,7 @@ func NoUTF8(path string, info os.FileInfo) error {
 func InvalidUTF8(path string, info os.FileInfo) error {
 	for _, r := range info.Name() {
 		if !runeValid(r) {
-			return fmt.Errorf("contains invalid unicode")
+			return newError("invalid-unicode", nil)
 		}
 	}
 
@@ -1,7 +1,6 @@
 package rules
 
 import (
-	"fmt"
 	"os"
 	"strings"
 )
@@ -26,7 +25,7 @@ func ValidDSCFilename(path string, info os.FileInfo) error {
 	}
 
 	if len(badChars) > 0 {
-		return fmt.Errorf("contains invalid characters: %s", joinRunes(badChars))
+		return newError("dsc-invalid-chars", Params{"chars": joinRunes(badChars)})
 	}
 
 	return nil
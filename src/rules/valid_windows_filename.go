@@ -1,7 +1,6 @@
 package rules
 
 import (
-	"fmt"
 	"os"
 	"strings"
 )
@@ -44,16 +43,16 @@ func ValidWindowsFilename(path string, info os.FileInfo) error {
 	}
 
 	if len(badChars) > 0 {
-		return fmt.Errorf("contains invalid characters: %s", joinRunes(badChars))
+		return newError("windows-invalid-chars", Params{"chars": joinRunes(badChars)})
 	}
 	if badName {
-		return fmt.Errorf("uses a reserved file name")
+		return newError("windows-reserved-name", nil)
 	}
 	if strings.HasSuffix(name, " ") {
-		return fmt.Errorf("has a trailing space")
+		return newError("windows-trailing-space", nil)
 	}
 	if strings.HasSuffix(name, ".") {
-		return fmt.Errorf("has a trailing period")
+		return newError("windows-trailing-period", nil)
 	}
 
 	return nil
@@ -1,7 +1,9 @@
 package rules
 
 import (
+	"fmt"
 	"os"
+	"strings"
 )
 
 // Criticality defines how important a validator is
@@ -32,6 +34,34 @@ func (c Criticality) String() string {
 	}
 }
 
+// ParseCriticality returns the Criticality whose name matches s, ignoring
+// case, or an error if there's no such criticality
+func ParseCriticality(s string) (Criticality, error) {
+	for _, c := range []Criticality{CCritical, CHigh, CNormal, CLow} {
+		if strings.EqualFold(s, c.String()) {
+			return c, nil
+		}
+	}
+
+	return CNormal, fmt.Errorf("unknown criticality %q", s)
+}
+
+// MarshalText implements encoding.TextMarshaler so criticalities are written
+// by name in structured reports
+func (c Criticality) MarshalText() ([]byte, error) {
+	return []byte(c.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler so criticalities can be
+// read back from structured reports
+func (c *Criticality) UnmarshalText(text []byte) error {
+	var parsed, err = ParseCriticality(string(text))
+	if err == nil {
+		*c = parsed
+	}
+	return err
+}
+
 // ValidatorFunc is the function called by a validator to determine if a path
 // is invalid in any way
 type ValidatorFunc func(path string, info os.FileInfo) error
@@ -57,33 +87,47 @@ type Validator struct {
 
 	// stopOnFailure flags future validators not to run if this validator failed
 	stopOnFailure bool
+
+	// params describes any settings built into vf, such as a length limit,
+	// so they can be recorded in a run's provenance
+	params Params
 }
 
 // Validate checks for errors in the validator function and returns the
 // (potentially updated) failure list
 func (v Validator) Validate(path string, info os.FileInfo, fList []Failure) []Failure {
+	if !v.shouldRun(fList) {
+		return fList
+	}
+
+	var err = v.vf(path, info)
+	if err != nil {
+		return append(fList, Failure{v, err})
+	}
+	return fList
+}
+
+// shouldRun returns true if the validator function needs to be called given
+// the failures found so far
+func (v Validator) shouldRun(fList []Failure) bool {
 	// Allow for placeholder validators
 	if v.vf == nil {
-		return fList
+		return false
 	}
 
 	var l = len(fList)
 	// If this validator isn't supposed to report already-failed items, break out
 	// now if there are existing failures
 	if v.skipOnPreviousFailures && l > 0 {
-		return fList
+		return false
 	}
 
 	// If the previous validator should stop all validations, break out now
 	if l > 0 && fList[l-1].V.stopOnFailure {
-		return fList
+		return false
 	}
 
-	var err = v.vf(path, info)
-	if err != nil {
-		return append(fList, Failure{v, err})
-	}
-	return fList
+	return true
 }
 
 // IsImportant reports whether this validator should be considered necessary
@@ -147,12 +191,20 @@ func RegisterValidatorLow(name string, validate ValidatorFunc) {
 // RegisterCustomValidator creates a validator with explicitly set values for
 // priority and failure modes, and puts that in the validator list
 func RegisterCustomValidator(name string, validate ValidatorFunc, c Criticality, priority int8, skipOnPreviousFailures, stopOnFailure bool) {
-	register(Validator{name, validate, priority, c, skipOnPreviousFailures, stopOnFailure})
+	register(Validator{
+		Name:                   name,
+		vf:                     validate,
+		priority:               priority,
+		Criticality:            c,
+		skipOnPreviousFailures: skipOnPreviousFailures,
+		stopOnFailure:          stopOnFailure,
+	})
 }
 
-// NukeValidatorList erases all entries from the list of known validators.
-// This is primarily for custom use cases where a whitelist approach is
-// preferable to the validators which auto-register themselves
+// NukeValidatorList erases all entries from the list of known validators,
+// except for the "broken-file" placeholder the engine reports unreadable
+// paths with.  This is primarily for custom use cases where a whitelist
+// approach is preferable to the validators which auto-register themselves
 func NukeValidatorList() {
-	validators = nil
+	validators = ValidatorList{badFileValidator}
 }
@@ -0,0 +1,46 @@
+package rules
+
+import (
+	"testing"
+)
+
+var criticalityTests = []struct {
+	name     string
+	expected Criticality
+	good     bool
+}{
+	{"critical", CCritical, true},
+	{"High", CHigh, true},
+	{"NORMAL", CNormal, true},
+	{"low", CLow, true},
+	{"lowest", CNormal, false},
+	{"", CNormal, false},
+}
+
+func TestParseCriticality(t *testing.T) {
+	for _, ct := range criticalityTests {
+		var c, err = ParseCriticality(ct.name)
+		if err != nil && ct.good {
+			t.Errorf("Expected %#v to parse, but got error: %s", ct.name, err)
+			continue
+		}
+		if err == nil && !ct.good {
+			t.Errorf("Expected %#v to fail parsing, but no error", ct.name)
+			continue
+		}
+		if c != ct.expected {
+			t.Errorf("Expected %#v to parse as %s, but got %s", ct.name, ct.expected, c)
+		}
+	}
+}
+
+func TestNukeValidatorList(t *testing.T) {
+	var saved = validators
+	defer func() { validators = saved }()
+
+	NukeValidatorList()
+	var vList = NewEngine().Validators()
+	if len(vList) != 1 || vList[0].Name != "broken-file" {
+		t.Errorf("Expected only broken-file to remain, got %#v", vList)
+	}
+}
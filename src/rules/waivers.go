@@ -0,0 +1,161 @@
+package rules
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+)
+
+// waiverDateFormat is the layout for waiver expiration dates
+const waiverDateFormat = "2006-01-02"
+
+// Waiver accepts one validator's failure on one specific path, recording who
+// approved the exception, why, and until when.  Code is optional, and limits
+// the waiver to a single kind of failure.  Expires is optional as well; a
+// waiver with no expiration never resurfaces.
+type Waiver struct {
+	Path          string `json:"path"`
+	Validator     string `json:"validator"`
+	Code          string `json:"code,omitempty"`
+	ApprovedBy    string `json:"approved_by"`
+	Justification string `json:"justification"`
+	Expires       string `json:"expires,omitempty"`
+
+	expires time.Time
+}
+
+// WaivedFailure pairs a failure with the waiver which suppressed it
+type WaivedFailure struct {
+	Path    string
+	Failure Failure
+	Waiver  Waiver
+}
+
+// WaiverList holds the waivers an Engine consults before reporting failures
+type WaiverList struct {
+	waivers []Waiver
+	now     func() time.Time
+}
+
+// NewWaiverList validates the given waivers and returns a list an Engine can
+// use.  Waivers must have a path, validator, approver, and justification, and
+// expiration dates must be in YYYY-MM-DD format.  The validator must be
+// registered, and like skipping, waivers can't be used on critical
+// validators.
+func NewWaiverList(waivers []Waiver) (*WaiverList, error) {
+	var criticality = make(map[string]Criticality)
+	for _, v := range validators {
+		criticality[v.Name] = v.Criticality
+	}
+
+	var wl = &WaiverList{now: time.Now}
+	for i, w := range waivers {
+		if w.Path == "" || w.Validator == "" || w.ApprovedBy == "" || w.Justification == "" {
+			return nil, fmt.Errorf("waiver %d: path, validator, approved_by, and justification are required", i+1)
+		}
+
+		var c, ok = criticality[w.Validator]
+		if !ok {
+			return nil, fmt.Errorf("waiver %d: unknown validator %q", i+1, w.Validator)
+		}
+		if c == CCritical {
+			return nil, fmt.Errorf("waiver %d: %q is a critical validator and can't be waived", i+1, w.Validator)
+		}
+
+		if w.Expires != "" {
+			var t, err = time.ParseInLocation(waiverDateFormat, w.Expires, time.Local)
+			if err != nil {
+				return nil, fmt.Errorf("waiver %d: invalid expiration date %q", i+1, w.Expires)
+			}
+			// Waivers are good through the end of their expiration day
+			w.expires = t.AddDate(0, 0, 1)
+		}
+
+		w.Path = filepath.FromSlash(w.Path)
+		wl.waivers = append(wl.waivers, w)
+	}
+
+	return wl, nil
+}
+
+// LoadWaivers reads a JSON list of waivers from the given file
+func LoadWaivers(filename string) (*WaiverList, error) {
+	var data, err = ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	var waivers []Waiver
+	err = json.Unmarshal(data, &waivers)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewWaiverList(waivers)
+}
+
+// Expired returns true if the waiver's expiration date has passed
+func (w Waiver) Expired(now time.Time) bool {
+	return !w.expires.IsZero() && !now.Before(w.expires)
+}
+
+// matches returns true if the waiver covers the given failure on path
+func (w Waiver) matches(path string, f Failure) bool {
+	if w.Path != path || w.Validator != f.V.Name {
+		return false
+	}
+	return w.Code == "" || w.Code == f.Code()
+}
+
+// apply splits the failure list into failures which should still be reported
+// and those which were waived.  Failures covered only by expired waivers are
+// reported, with a note about the expiration.  Critical failures are always
+// reported.
+func (wl *WaiverList) apply(path string, fl []Failure) (kept []Failure, waived []WaivedFailure) {
+	if wl == nil {
+		return fl, nil
+	}
+
+	var now = wl.now()
+	for _, f := range fl {
+		if f.V.Criticality == CCritical {
+			kept = append(kept, f)
+			continue
+		}
+
+		var expired *Waiver
+		var ok bool
+		for i, w := range wl.waivers {
+			if !w.matches(path, f) {
+				continue
+			}
+			if w.Expired(now) {
+				expired = &wl.waivers[i]
+				continue
+			}
+			waived = append(waived, WaivedFailure{Path: path, Failure: f, Waiver: w})
+			ok = true
+			break
+		}
+
+		if ok {
+			continue
+		}
+		if expired != nil {
+			var e, isError = f.E.(*Error)
+			if isError {
+				var copied = *e
+				e = &copied
+			} else {
+				e = newError(f.Code(), Params{"message": f.E.Error()})
+			}
+			e.expiredWaiver = expired
+			f.E = e
+		}
+		kept = append(kept, f)
+	}
+
+	return kept, waived
+}
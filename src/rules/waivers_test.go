@@ -0,0 +1,39 @@
+package rules
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewWaiverListRejects(t *testing.T) {
+	var tests = map[string]string{
+		"no-spaces-typo": "unknown validator",
+		"broken-file":    "can't be waived",
+	}
+	for name, expected := range tests {
+		var _, err = NewWaiverList([]Waiver{{Path: "a", Validator: name, ApprovedBy: "me", Justification: "because"}})
+		if err == nil || !strings.Contains(err.Error(), expected) {
+			t.Errorf("Expected a waiver for %q to fail with %q, got %v", name, expected, err)
+		}
+	}
+}
+
+func TestWaiverListKeepsCritical(t *testing.T) {
+	// Even a waiver list built by hand can't hide critical failures
+	var wl = &WaiverList{
+		waivers: []Waiver{{Path: "a", Validator: "broken-file"}, {Path: "a", Validator: "no-spaces"}},
+		now:     time.Now,
+	}
+	var fl = []Failure{
+		{V: Validator{Name: "broken-file", Criticality: CCritical}, E: newError("unreadable", Params{"error": "x"})},
+		{V: Validator{Name: "no-spaces", Criticality: CNormal}, E: newError("space", nil)},
+	}
+	var kept, waived = wl.apply("a", fl)
+	if len(kept) != 1 || kept[0].V.Name != "broken-file" {
+		t.Errorf("Expected only the critical failure to be kept, got %#v", kept)
+	}
+	if len(waived) != 1 || waived[0].Failure.V.Name != "no-spaces" {
+		t.Errorf("Expected only the normal failure to be waived, got %#v", waived)
+	}
+}
@@ -0,0 +1,312 @@
+// Package xlsx writes simple Office Open XML spreadsheets: plain string and
+// numeric cells, with optional bold, frozen, and filterable header rows.  It
+// exists so reports can be opened in Excel without the encoding problems of
+// TSV files, and without requiring any external tools.
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+)
+
+const (
+	nsMain = "http://schemas.openxmlformats.org/spreadsheetml/2006/main"
+	nsRel  = "http://schemas.openxmlformats.org/officeDocument/2006/relationships"
+	nsPkg  = "http://schemas.openxmlformats.org/package/2006/relationships"
+	xmlHdr = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>` + "\n"
+)
+
+// Workbook is a list of sheets which can be written out as an xlsx file
+type Workbook struct {
+	sheets []*Sheet
+}
+
+// Sheet is a single worksheet.  When Header is true, the first row is bold,
+// frozen so it stays visible while scrolling, and given an auto-filter.
+type Sheet struct {
+	Name   string
+	Header bool
+	rows   [][]interface{}
+	widths map[int]float64
+}
+
+// New returns an empty workbook
+func New() *Workbook {
+	return &Workbook{}
+}
+
+// AddSheet appends a new, empty sheet to the workbook.  Excel limits sheet
+// names to 31 characters and disallows a few characters; callers must choose
+// names which follow those rules.
+func (wb *Workbook) AddSheet(name string) *Sheet {
+	var s = &Sheet{Name: name, widths: make(map[int]float64)}
+	wb.sheets = append(wb.sheets, s)
+	return s
+}
+
+// AddRow appends a row of cells.  Strings are written as text; all integer
+// and float types are written as numbers; anything else is formatted with
+// fmt.Sprint and written as text.
+func (s *Sheet) AddRow(cells ...interface{}) {
+	s.rows = append(s.rows, cells)
+}
+
+// SetColumnWidth sets the width, in characters, of the zero-indexed column
+func (s *Sheet) SetColumnWidth(col int, width float64) {
+	s.widths[col] = width
+}
+
+// columnName converts a zero-indexed column number to its letter name: 0 is
+// "A", 25 is "Z", 26 is "AA", and so on
+func columnName(col int) string {
+	var name string
+	for col++; col > 0; col = (col - 1) / 26 {
+		name = string(rune('A'+(col-1)%26)) + name
+	}
+	return name
+}
+
+// cellRef returns the A1-style reference for zero-indexed row and column
+func cellRef(row, col int) string {
+	return fmt.Sprintf("%s%d", columnName(col), row+1)
+}
+
+// escapeText makes s safe for XML.  Characters XML can't represent at all are
+// written using the _xHHHH_ escapes spreadsheet applications understand, and
+// text which already looks like one of those escapes has its underscore
+// escaped so it isn't decoded.
+func escapeText(s string) string {
+	var buf bytes.Buffer
+	for len(s) > 0 {
+		var r, size = utf8.DecodeRuneInString(s)
+		if r < 0x20 && r != '\t' && r != '\n' && r != '\r' {
+			fmt.Fprintf(&buf, "_x%04X_", r)
+		} else if isEscapeSequence(s) {
+			buf.WriteString("_x005F_")
+		} else {
+			xml.EscapeText(&buf, []byte(s[:size]))
+		}
+		s = s[size:]
+	}
+	return buf.String()
+}
+
+// isEscapeSequence returns true if s starts with an _xHHHH_ escape
+func isEscapeSequence(s string) bool {
+	if len(s) < 7 || s[0] != '_' || s[1] != 'x' || s[6] != '_' {
+		return false
+	}
+	for _, c := range s[2:6] {
+		if !strings.ContainsRune("0123456789abcdefABCDEF", c) {
+			return false
+		}
+	}
+	return true
+}
+
+// cellXML returns the XML for a single cell
+func cellXML(ref string, style int, v interface{}) string {
+	var attrs = fmt.Sprintf(`r="%s"`, ref)
+	if style != 0 {
+		attrs += fmt.Sprintf(` s="%d"`, style)
+	}
+
+	switch n := v.(type) {
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return fmt.Sprintf(`<c %s><v>%v</v></c>`, attrs, n)
+	case string:
+		return fmt.Sprintf(`<c %s t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`, attrs, escapeText(n))
+	default:
+		return cellXML(ref, style, fmt.Sprint(v))
+	}
+}
+
+// lastCell returns the reference of the bottom-right cell with any data
+func (s *Sheet) lastCell() string {
+	var cols = 1
+	for _, row := range s.rows {
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+	var rows = len(s.rows)
+	if rows == 0 {
+		rows = 1
+	}
+	return cellRef(rows-1, cols-1)
+}
+
+// xml returns the worksheet part for this sheet
+func (s *Sheet) xml() string {
+	var b strings.Builder
+	b.WriteString(xmlHdr)
+	fmt.Fprintf(&b, `<worksheet xmlns="%s" xmlns:r="%s">`, nsMain, nsRel)
+
+	if s.Header {
+		b.WriteString(`<sheetViews><sheetView workbookViewId="0">`)
+		b.WriteString(`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>`)
+		b.WriteString(`</sheetView></sheetViews>`)
+	}
+
+	if len(s.widths) > 0 {
+		b.WriteString("<cols>")
+		for col := 0; col <= maxKey(s.widths); col++ {
+			if w, ok := s.widths[col]; ok {
+				fmt.Fprintf(&b, `<col min="%d" max="%d" width="%g" customWidth="1"/>`, col+1, col+1, w)
+			}
+		}
+		b.WriteString("</cols>")
+	}
+
+	b.WriteString("<sheetData>")
+	for i, row := range s.rows {
+		var style int
+		if i == 0 && s.Header {
+			style = 1
+		}
+		fmt.Fprintf(&b, `<row r="%d">`, i+1)
+		for j, v := range row {
+			b.WriteString(cellXML(cellRef(i, j), style, v))
+		}
+		b.WriteString("</row>")
+	}
+	b.WriteString("</sheetData>")
+
+	if s.Header {
+		fmt.Fprintf(&b, `<autoFilter ref="A1:%s"/>`, s.lastCell())
+	}
+
+	b.WriteString("</worksheet>")
+	return b.String()
+}
+
+func maxKey(m map[int]float64) int {
+	var max int
+	for k := range m {
+		if k > max {
+			max = k
+		}
+	}
+	return max
+}
+
+// quoteSheetName returns the sheet name as it's referenced in formulas
+func quoteSheetName(name string) string {
+	return "'" + strings.Replace(name, "'", "''", -1) + "'"
+}
+
+func (wb *Workbook) contentTypesXML() string {
+	var b strings.Builder
+	b.WriteString(xmlHdr)
+	b.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	b.WriteString(`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+	b.WriteString(`<Override PartName="/xl/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.styles+xml"/>`)
+	for i := range wb.sheets {
+		fmt.Fprintf(&b, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, i+1)
+	}
+	b.WriteString(`</Types>`)
+	return b.String()
+}
+
+func rootRelsXML() string {
+	return xmlHdr + fmt.Sprintf(`<Relationships xmlns="%s">`, nsPkg) +
+		fmt.Sprintf(`<Relationship Id="rId1" Type="%s/officeDocument" Target="xl/workbook.xml"/>`, nsRel) +
+		`</Relationships>`
+}
+
+func (wb *Workbook) workbookXML() string {
+	var b strings.Builder
+	b.WriteString(xmlHdr)
+	fmt.Fprintf(&b, `<workbook xmlns="%s" xmlns:r="%s"><sheets>`, nsMain, nsRel)
+	for i, s := range wb.sheets {
+		fmt.Fprintf(&b, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, escapeText(s.Name), i+1, i+1)
+	}
+	b.WriteString(`</sheets>`)
+
+	// Excel expects a hidden defined name for each sheet's auto-filter range
+	var names []string
+	for i, s := range wb.sheets {
+		if s.Header {
+			names = append(names, fmt.Sprintf(`<definedName name="_xlnm._FilterDatabase" localSheetId="%d" hidden="1">%s!$A$1:$%s</definedName>`,
+				i, escapeText(quoteSheetName(s.Name)), absoluteRef(s.lastCell())))
+		}
+	}
+	if len(names) > 0 {
+		b.WriteString("<definedNames>" + strings.Join(names, "") + "</definedNames>")
+	}
+
+	b.WriteString(`</workbook>`)
+	return b.String()
+}
+
+// absoluteRef turns "B12" into "B$12"; the leading "$" is added by the caller
+func absoluteRef(ref string) string {
+	var i = strings.IndexAny(ref, "0123456789")
+	return ref[:i] + "$" + ref[i:]
+}
+
+func (wb *Workbook) workbookRelsXML() string {
+	var b strings.Builder
+	b.WriteString(xmlHdr)
+	fmt.Fprintf(&b, `<Relationships xmlns="%s">`, nsPkg)
+	for i := range wb.sheets {
+		fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="%s/worksheet" Target="worksheets/sheet%d.xml"/>`, i+1, nsRel, i+1)
+	}
+	fmt.Fprintf(&b, `<Relationship Id="rId%d" Type="%s/styles" Target="styles.xml"/>`, len(wb.sheets)+1, nsRel)
+	b.WriteString(`</Relationships>`)
+	return b.String()
+}
+
+// stylesXML defines two cell formats: the default, and bold for headers
+func stylesXML() string {
+	return xmlHdr + fmt.Sprintf(`<styleSheet xmlns="%s">`, nsMain) +
+		`<fonts count="2"><font><sz val="11"/><name val="Calibri"/></font><font><b/><sz val="11"/><name val="Calibri"/></font></fonts>` +
+		`<fills count="2"><fill><patternFill patternType="none"/></fill><fill><patternFill patternType="gray125"/></fill></fills>` +
+		`<borders count="1"><border><left/><right/><top/><bottom/><diagonal/></border></borders>` +
+		`<cellStyleXfs count="1"><xf numFmtId="0" fontId="0" fillId="0" borderId="0"/></cellStyleXfs>` +
+		`<cellXfs count="2"><xf numFmtId="0" fontId="0" fillId="0" borderId="0" xfId="0"/>` +
+		`<xf numFmtId="0" fontId="1" fillId="0" borderId="0" xfId="0" applyFont="1"/></cellXfs>` +
+		`<cellStyles count="1"><cellStyle name="Normal" xfId="0" builtinId="0"/></cellStyles>` +
+		`</styleSheet>`
+}
+
+// part is a single file within the xlsx zip archive
+type part struct {
+	name string
+	data string
+}
+
+// Write sends the workbook to w as a complete xlsx file
+func (wb *Workbook) Write(w io.Writer) error {
+	var parts = []part{
+		{"[Content_Types].xml", wb.contentTypesXML()},
+		{"_rels/.rels", rootRelsXML()},
+		{"xl/workbook.xml", wb.workbookXML()},
+		{"xl/_rels/workbook.xml.rels", wb.workbookRelsXML()},
+		{"xl/styles.xml", stylesXML()},
+	}
+	for i, s := range wb.sheets {
+		parts = append(parts, part{fmt.Sprintf("xl/worksheets/sheet%d.xml", i+1), s.xml()})
+	}
+
+	var z = zip.NewWriter(w)
+	for _, p := range parts {
+		var f, err = z.Create(p.name)
+		if err != nil {
+			return err
+		}
+		_, err = io.WriteString(f, p.data)
+		if err != nil {
+			return err
+		}
+	}
+
+	return z.Close()
+}
@@ -0,0 +1,101 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestColumnName(t *testing.T) {
+	var tests = map[int]string{0: "A", 25: "Z", 26: "AA", 51: "AZ", 52: "BA", 701: "ZZ", 702: "AAA"}
+	for col, expected := range tests {
+		var got = columnName(col)
+		if got != expected {
+			t.Errorf("Expected column %d to be %q, got %q", col, expected, got)
+		}
+	}
+}
+
+func TestEscapeText(t *testing.T) {
+	var tests = map[string]string{
+		"a<b>&\x05ü":        "a&lt;b&gt;&amp;_x0005_ü",
+		"literal _x0041_":   "literal _x005F_x0041_",
+		"_x12_ and _xZZZZ_": "_x12_ and _xZZZZ_",
+	}
+	for in, expected := range tests {
+		var got = escapeText(in)
+		if got != expected {
+			t.Errorf("Expected %q to escape as %q, got %q", in, expected, got)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var wb = New()
+	var s = wb.AddSheet("Failures")
+	s.Header = true
+	s.AddRow("Path", "Size")
+	s.AddRow("café/naïve.txt", 1024)
+	s.AddRow("bad\x05name", int64(7))
+
+	var buf bytes.Buffer
+	var err = wb.Write(&buf)
+	if err != nil {
+		t.Fatalf("Unable to write workbook: %s", err)
+	}
+
+	var z *zip.Reader
+	z, err = zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("Unable to read workbook as a zip file: %s", err)
+	}
+
+	var parts = make(map[string]string)
+	for _, f := range z.File {
+		var rc, _ = f.Open()
+		var data, _ = ioutil.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(data)
+
+		// Every part must be well-formed XML
+		var d = xml.NewDecoder(bytes.NewReader(data))
+		for {
+			var _, err = d.Token()
+			if err != nil {
+				if err.Error() != "EOF" {
+					t.Errorf("%s is not valid XML: %s", f.Name, err)
+				}
+				break
+			}
+		}
+	}
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/workbook.xml",
+		"xl/_rels/workbook.xml.rels", "xl/styles.xml", "xl/worksheets/sheet1.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Errorf("Missing part %s", name)
+		}
+	}
+
+	var sheet = parts["xl/worksheets/sheet1.xml"]
+	var expected = []string{
+		`state="frozen"`,
+		`<autoFilter ref="A1:B3"/>`,
+		`<c r="A1" s="1" t="inlineStr"><is><t xml:space="preserve">Path</t></is></c>`,
+		`<t xml:space="preserve">café/naïve.txt</t>`,
+		`<c r="B2"><v>1024</v></c>`,
+		`bad_x0005_name`,
+	}
+	for _, e := range expected {
+		if !strings.Contains(sheet, e) {
+			t.Errorf("Expected sheet XML to contain %q", e)
+		}
+	}
+
+	if !strings.Contains(parts["xl/workbook.xml"], `&#39;Failures&#39;!$A$1:$B$3`) {
+		t.Errorf("Expected workbook to define the auto-filter range")
+	}
+}